@@ -0,0 +1,108 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// extractArchive unpacks an -archive .tar.gz into a new temporary directory
+// and returns the directory to scan as the home directory: the archive root,
+// or its only top-level directory when everything sits under one. Only
+// regular files and directories are extracted; links and entries that would
+// land outside the directory are skipped. cleanup removes the directory and
+// is always safe to call.
+func extractArchive(src string, debug bool) (root string, cleanup func(), err error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return "", func() {}, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return "", func() {}, fmt.Errorf("failed to read %s: %w", src, err)
+	}
+	defer gz.Close()
+
+	dir, err := os.MkdirTemp("", "go-browser-inventory-archive-")
+	if err != nil {
+		return "", func() {}, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			cleanup()
+			return "", func() {}, fmt.Errorf("failed to read %s: %w", src, err)
+		}
+
+		name := filepath.FromSlash(strings.TrimPrefix(hdr.Name, "./"))
+		if name == "" || name == "." {
+			continue
+		}
+		if !filepath.IsLocal(name) {
+			if debug {
+				fmt.Fprintf(os.Stderr, "Warning: skipping archive entry outside the root: %s\n", hdr.Name)
+			}
+			continue
+		}
+		target := filepath.Join(dir, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0o700)
+		case tar.TypeReg:
+			err = extractFile(tr, target)
+		default:
+			if debug {
+				fmt.Fprintf(os.Stderr, "Warning: skipping archive entry that isn't a file or directory: %s\n", hdr.Name)
+			}
+			continue
+		}
+		if err != nil {
+			cleanup()
+			return "", func() {}, fmt.Errorf("failed to extract %s: %w", hdr.Name, err)
+		}
+	}
+
+	return archiveRoot(dir), cleanup, nil
+}
+
+// extractFile writes one archive entry to target, creating its parents
+func extractFile(r io.Reader, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// archiveRoot descends into dir's only entry while it is a directory that
+// isn't itself browser data, so an archive of home/alice/ scans alice's home
+func archiveRoot(dir string) string {
+	for {
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) != 1 || !entries[0].IsDir() || strings.HasPrefix(entries[0].Name(), ".") {
+			return dir
+		}
+		dir = filepath.Join(dir, entries[0].Name())
+	}
+}
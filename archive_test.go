@@ -0,0 +1,163 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeArchive writes a .tar.gz of the given headers to path, using each
+// regular file's name as its contents
+func writeArchive(t *testing.T, path string, headers []tar.Header) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, hdr := range headers {
+		var body []byte
+		if hdr.Typeflag == tar.TypeReg {
+			body = []byte(hdr.Name)
+			hdr.Size = int64(len(body))
+		}
+		if hdr.Mode == 0 {
+			hdr.Mode = 0o644
+		}
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestExtractArchive unpacks an archive that tries to write outside the
+// extraction directory through traversal, absolute paths, and links
+func TestExtractArchive(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	outside := t.TempDir()
+	src := filepath.Join(t.TempDir(), "home.tar.gz")
+
+	prefs := "home/alice/.config/google-chrome/Default/Preferences"
+	writeArchive(t, src, []tar.Header{
+		{Name: "./home/", Typeflag: tar.TypeDir, Mode: 0o755},
+		{Name: prefs, Typeflag: tar.TypeReg},
+		{Name: "../escape.txt", Typeflag: tar.TypeReg},
+		{Name: "home/alice/../../../escape.txt", Typeflag: tar.TypeReg},
+		{Name: filepath.ToSlash(filepath.Join(outside, "absolute.txt")), Typeflag: tar.TypeReg},
+		{Name: "home/alice/link", Typeflag: tar.TypeSymlink, Linkname: outside},
+		{Name: "home/alice/link/through-symlink.txt", Typeflag: tar.TypeReg},
+		{Name: "home/alice/hardlink", Typeflag: tar.TypeLink, Linkname: "../../../escape.txt"},
+	})
+
+	root, cleanup, err := extractArchive(src, false)
+	if err != nil {
+		t.Fatalf("extractArchive: %v", err)
+	}
+	defer cleanup()
+
+	// Everything lands in one new directory under TMPDIR
+	dirs, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) != 1 || !dirs[0].IsDir() {
+		t.Fatalf("TMPDIR holds %v, want only the extraction directory", dirs)
+	}
+	dir := filepath.Join(tmp, dirs[0].Name())
+	if want := filepath.Join(dir, "home", "alice"); root != want {
+		t.Errorf("root = %s, want %s", root, want)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(prefs))); err != nil || string(data) != prefs {
+		t.Errorf("Preferences = %q, %v; want it extracted", data, err)
+	}
+
+	// Links were skipped, so the entry under "link" made a plain directory
+	if info, err := os.Lstat(filepath.Join(root, "link")); err != nil || !info.IsDir() {
+		t.Errorf("link = %v, %v; want a directory, not a symlink", info, err)
+	}
+	if _, err := os.Lstat(filepath.Join(root, "hardlink")); !os.IsNotExist(err) {
+		t.Errorf("hardlink was extracted: %v", err)
+	}
+
+	// Nothing escaped the extraction directory
+	for _, parent := range []string{outside, filepath.Dir(src)} {
+		entries, err := os.ReadDir(parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if e.Name() != filepath.Base(src) {
+				t.Errorf("%s was written outside the extraction directory", filepath.Join(parent, e.Name()))
+			}
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(filepath.Dir(tmp), "escape.txt")); !os.IsNotExist(err) {
+		t.Errorf("escape.txt was written next to TMPDIR: %v", err)
+	}
+
+	cleanup()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("cleanup left %s behind: %v", dir, err)
+	}
+}
+
+func TestExtractArchiveInvalid(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	dir := t.TempDir()
+	notGzip := filepath.Join(dir, "plain.tar.gz")
+	if err := os.WriteFile(notGzip, []byte("not gzip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range []string{notGzip, filepath.Join(dir, "missing.tar.gz")} {
+		_, cleanup, err := extractArchive(src, false)
+		cleanup()
+		if err == nil {
+			t.Errorf("extractArchive(%s) succeeded", filepath.Base(src))
+		}
+	}
+	if entries, _ := os.ReadDir(os.Getenv("TMPDIR")); len(entries) != 0 {
+		t.Errorf("failed extractions left %d entries in TMPDIR", len(entries))
+	}
+}
+
+func TestArchiveRoot(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{name: "single user", paths: []string{"home/alice/.config/x"}, want: "home/alice"},
+		{name: "browser data at the top", paths: []string{".mozilla/firefox/x"}, want: ""},
+		{name: "two users", paths: []string{"home/alice/x", "home/bob/x"}, want: "home"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, p := range tt.paths {
+				path := filepath.Join(dir, filepath.FromSlash(p))
+				if err := os.MkdirAll(path, 0o700); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := strings.TrimPrefix(filepath.ToSlash(strings.TrimPrefix(archiveRoot(dir), dir)), "/")
+			if got != tt.want {
+				t.Errorf("archiveRoot() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
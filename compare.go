@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// comparedExtension is an extension ID and the hosts it was found on
+type comparedExtension struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Hosts []string `json:"hosts"`
+}
+
+// hostDelta lists the extensions found only on one host
+type hostDelta struct {
+	Host       string              `json:"host"`
+	Extensions []comparedExtension `json:"extensions"`
+}
+
+// hostInventory is one loaded -json export
+type hostInventory struct {
+	Host string
+	Out  output
+}
+
+// loadInventories reads -json exports, flat or grouped with -group,
+// labelling each by its meta hostname, or by file name when the export has
+// none. A hostname seen in an earlier file is suffixed with the file name so
+// two exports of one host stay apart.
+func loadInventories(paths []string) ([]hostInventory, error) {
+	var inventories []hostInventory
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// Exports copied through some tools pick up a byte order mark
+		out, err := parseInventory(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		host := filepath.Base(path)
+		if out.Meta != nil && out.Meta.Hostname != "" {
+			host = out.Meta.Hostname
+			if seen[host] {
+				host = fmt.Sprintf("%s (%s)", host, filepath.Base(path))
+			}
+		}
+		seen[host] = true
+		inventories = append(inventories, hostInventory{Host: host, Out: out})
+	}
+	return inventories, nil
+}
+
+// parseInventory decodes a -json export. A -group export's extensions are
+// flattened in browser and profile name order. Any other JSON document is
+// rejected rather than read as an empty inventory.
+func parseInventory(data []byte) (output, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return output{}, err
+	}
+	var out output
+	if err := json.Unmarshal(data, &out); err != nil {
+		return output{}, err
+	}
+	if _, ok := keys["extensions"]; ok {
+		return out, nil
+	}
+	if _, ok := keys["groups"]; !ok {
+		return output{}, fmt.Errorf("not a -json inventory: no extensions or groups")
+	}
+
+	var grouped groupedOutput
+	if err := json.Unmarshal(data, &grouped); err != nil {
+		return output{}, err
+	}
+	names := make([]string, 0, len(grouped.Groups))
+	for name := range grouped.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profiles := grouped.Groups[name].Profiles
+		profileNames := make([]string, 0, len(profiles))
+		for profile := range profiles {
+			profileNames = append(profileNames, profile)
+		}
+		sort.Strings(profileNames)
+		for _, profile := range profileNames {
+			out.Extensions = append(out.Extensions, profiles[profile].Extensions...)
+		}
+	}
+	return out, nil
+}
+
+// compareHosts partitions extension IDs across the inventories into those on
+// every host, those on some, and per host those on no other. Lists are sorted
+// by ID; hosts keep the order given.
+func compareHosts(inventories []hostInventory) compareOutput {
+	byID := make(map[string]*comparedExtension)
+	for _, inv := range inventories {
+		onHost := make(map[string]bool)
+		for _, ext := range inv.Out.Extensions {
+			if onHost[ext.ID] {
+				continue
+			}
+			onHost[ext.ID] = true
+			c, ok := byID[ext.ID]
+			if !ok {
+				c = &comparedExtension{ID: ext.ID, Name: ext.DisplayName()}
+				byID[ext.ID] = c
+			}
+			c.Hosts = append(c.Hosts, inv.Host)
+		}
+	}
+
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	out := compareOutput{Common: []comparedExtension{}, Partial: []comparedExtension{}}
+	unique := make(map[string][]comparedExtension)
+	for _, inv := range inventories {
+		out.Hosts = append(out.Hosts, inv.Host)
+	}
+	for _, id := range ids {
+		c := *byID[id]
+		switch {
+		case len(c.Hosts) == len(inventories):
+			out.Common = append(out.Common, c)
+		case len(c.Hosts) == 1:
+			unique[c.Hosts[0]] = append(unique[c.Hosts[0]], c)
+		default:
+			out.Partial = append(out.Partial, c)
+		}
+	}
+	for _, host := range out.Hosts {
+		exts := unique[host]
+		if exts == nil {
+			exts = []comparedExtension{}
+		}
+		out.Unique = append(out.Unique, hostDelta{Host: host, Extensions: exts})
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"go-browser-inventory/internal/browsers"
+)
+
+// compareExtensions is a small inventory spread over two browsers and
+// profiles
+var compareExtensions = []browsers.Extension{
+	{ID: "aaaa", Name: "Alpha", Browser: "Chrome", Profile: "Default"},
+	{ID: "bbbb", Name: "Beta", Browser: "Chrome", Profile: "Work"},
+	{ID: "cccc", Name: "Gamma", Browser: "Edge", Profile: "Default"},
+	{ID: "aaaa", Name: "Alpha", Browser: "Edge", Profile: "Default"},
+}
+
+// writeInventoryFile writes v as JSON to name in dir and returns its path
+func writeInventoryFile(t *testing.T, dir, name string, v any, bom bool) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bom {
+		data = append([]byte("\xef\xbb\xbf"), data...)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func sortedIDs(exts []browsers.Extension) []string {
+	var ids []string
+	for _, ext := range exts {
+		ids = append(ids, ext.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestLoadInventories(t *testing.T) {
+	dir := t.TempDir()
+	flat := writeInventoryFile(t, dir, "flat.json", output{
+		Extensions: compareExtensions,
+		Total:      len(compareExtensions),
+		Meta:       &scanMeta{Hostname: "vm"},
+	}, false)
+	grouped := writeInventoryFile(t, dir, "grouped.json", groupedOutput{
+		Groups: groupExtensions(compareExtensions),
+		Total:  len(compareExtensions),
+		Meta:   &scanMeta{Hostname: "vm"},
+	}, false)
+	bom := writeInventoryFile(t, dir, "laptop.json", output{Extensions: compareExtensions[:1]}, true)
+	emptyGroups := writeInventoryFile(t, dir, "empty.json", groupedOutput{Groups: map[string]browserGroup{}}, false)
+
+	inventories, err := loadInventories([]string{flat, grouped, bom, emptyGroups})
+	if err != nil {
+		t.Fatalf("loadInventories: %v", err)
+	}
+	wantHosts := []string{"vm", "vm (grouped.json)", "laptop.json", "empty.json"}
+	wantIDs := [][]string{
+		{"aaaa", "aaaa", "bbbb", "cccc"},
+		{"aaaa", "aaaa", "bbbb", "cccc"},
+		{"aaaa"},
+		nil,
+	}
+	if len(inventories) != len(wantHosts) {
+		t.Fatalf("loaded %d inventories, want %d", len(inventories), len(wantHosts))
+	}
+	for i, inv := range inventories {
+		if inv.Host != wantHosts[i] {
+			t.Errorf("inventory %d host = %q, want %q", i, inv.Host, wantHosts[i])
+		}
+		if got := sortedIDs(inv.Out.Extensions); !reflect.DeepEqual(got, wantIDs[i]) {
+			t.Errorf("%s extensions = %q, want %q", inv.Host, got, wantIDs[i])
+		}
+	}
+
+	// The grouped export keeps each extension's browser and profile
+	for _, ext := range inventories[1].Out.Extensions {
+		if ext.Browser == "" || ext.Profile == "" {
+			t.Errorf("flattened extension %+v lost its browser or profile", ext)
+		}
+	}
+}
+
+func TestLoadInventoriesInvalid(t *testing.T) {
+	dir := t.TempDir()
+	policy := writeInventoryFile(t, dir, "policy.json", policyOutput{Mode: "deny", Compliant: true}, false)
+	list := writeInventoryFile(t, dir, "list.json", compareExtensions, false)
+	garbage := filepath.Join(dir, "garbage.json")
+	if err := os.WriteFile(garbage, []byte(`{"extensions": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{policy, list, garbage, filepath.Join(dir, "missing.json")} {
+		if _, err := loadInventories([]string{path}); err == nil {
+			t.Errorf("loadInventories(%s) succeeded, want an error", filepath.Base(path))
+		}
+	}
+}
+
+func TestCompareHosts(t *testing.T) {
+	inventories := []hostInventory{
+		{Host: "web01", Out: output{Extensions: []browsers.Extension{
+			{ID: "common", Name: "Everywhere"},
+			{ID: "web", Name: "Web Only"},
+			{ID: "only01", Name: "One"},
+			{ID: "only01", Name: "One", Profile: "Other"},
+		}}},
+		{Host: "web02", Out: output{Extensions: []browsers.Extension{
+			{ID: "web", Name: "Web Only"},
+			{ID: "common", Name: "Everywhere"},
+		}}},
+		{Host: "laptop", Out: output{Extensions: []browsers.Extension{
+			{ID: "common", Name: "Everywhere"},
+			{ID: "only-laptop", ShortName: "Laptop", Name: "Laptop Only"},
+		}}},
+		{Host: "empty"},
+	}
+	got := compareHosts(inventories)
+	want := compareOutput{
+		Hosts:  []string{"web01", "web02", "laptop", "empty"},
+		Common: []comparedExtension{},
+		Partial: []comparedExtension{
+			{ID: "common", Name: "Everywhere", Hosts: []string{"web01", "web02", "laptop"}},
+			{ID: "web", Name: "Web Only", Hosts: []string{"web01", "web02"}},
+		},
+		Unique: []hostDelta{
+			{Host: "web01", Extensions: []comparedExtension{{ID: "only01", Name: "One", Hosts: []string{"web01"}}}},
+			{Host: "web02", Extensions: []comparedExtension{}},
+			{Host: "laptop", Extensions: []comparedExtension{{ID: "only-laptop", Name: "Laptop", Hosts: []string{"laptop"}}}},
+			{Host: "empty", Extensions: []comparedExtension{}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("compareHosts() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// Without the empty host, the extension on all three is common
+	got = compareHosts(inventories[:3])
+	if len(got.Common) != 1 || got.Common[0].ID != "common" {
+		t.Errorf("Common = %+v, want only common", got.Common)
+	}
+}
@@ -1,29 +1,52 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"go-browser-inventory/internal/browsers"
+	"go-browser-inventory/internal/webstore"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// DB wraps the SQLite connection
+// DB wraps the SQLite connection. It is safe for concurrent use: writes are
+// serialized by writeMu, and the database runs in WAL mode so reads proceed
+// alongside them.
 type DB struct {
-	conn *sql.DB
+	conn    *sql.DB
+	writeMu sync.Mutex
 }
 
-// NewDB initializes a new SQLite database connection
-func NewDB(path string) (*DB, error) {
-	conn, err := sql.Open("sqlite3", path)
+// NewDB initializes a new SQLite database connection with a cache table for
+// each browser name, typically BrowserInventory.BrowserNames. Names are used
+// in table names, so any that fail browsers.ValidBrowserName are rejected.
+func NewDB(path string, browserNames []string) (*DB, error) {
+	for _, browser := range browserNames {
+		if !browsers.ValidBrowserName(browser) {
+			return nil, fmt.Errorf("invalid browser name %q for a cache table", browser)
+		}
+	}
+
+	// WAL lets readers run while a scan is written, and immediate
+	// transactions take the write lock up front, so a transaction that reads
+	// before writing (UpdateExtensions) waits out the busy timeout for another
+	// process instead of failing with "database is locked" when it upgrades
+	conn, err := sql.Open("sqlite3", fileDSN(path, "_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	browsersList := []string{"Chrome", "Edge", "Firefox"}
-	for _, browser := range browsersList {
+	for _, browser := range browserNames {
 		// Use composite primary key (id, profile, version)
 		query := fmt.Sprintf(`
             CREATE TABLE IF NOT EXISTS %s_extensions (
@@ -32,7 +55,30 @@ func NewDB(path string) (*DB, error) {
                 browser TEXT NOT NULL,
                 version TEXT NOT NULL,
                 enabled INTEGER NOT NULL,
+                disabled_reason TEXT,
                 profile TEXT,
+                permissions TEXT,
+                host_permissions TEXT,
+                path TEXT,
+                short_name TEXT,
+                author TEXT,
+                homepage TEXT,
+                rating REAL,
+                builtin INTEGER NOT NULL DEFAULT 0,
+                install_source TEXT,
+                update_url TEXT,
+                version_name TEXT,
+                granted_permissions TEXT,
+                granted_host_permissions TEXT,
+                min_browser_version TEXT,
+                incognito_allowed INTEGER NOT NULL DEFAULT 0,
+                type TEXT,
+                installed_by TEXT,
+                duplicate_in_profile INTEGER NOT NULL DEFAULT 0,
+                installed_at INTEGER,
+                updated_at INTEGER,
+                first_seen INTEGER,
+                scan_order INTEGER,
                 timestamp INTEGER NOT NULL,
                 PRIMARY KEY (id, profile, version)
             )`, browser)
@@ -40,38 +86,279 @@ func NewDB(path string) (*DB, error) {
 			conn.Close()
 			return nil, fmt.Errorf("failed to create table %s_extensions: %w", browser, err)
 		}
+		if err := migrateColumns(conn, browser+"_extensions", addedColumns); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	// Rows in the per-browser tables are only rewritten when they change, so
+	// when each browser was last scanned is kept separately
+	query := `
+        CREATE TABLE IF NOT EXISTS cache_scans (
+            browser TEXT PRIMARY KEY,
+            timestamp INTEGER NOT NULL,
+            profiles INTEGER
+        )`
+	if _, err := conn.Exec(query); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create table cache_scans: %w", err)
+	}
+	if err := migrateColumns(conn, "cache_scans", addedScanColumns); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// Store listings are shared across browsers, keyed by store and extension ID
+	query = `
+        CREATE TABLE IF NOT EXISTS store_listings (
+            id TEXT NOT NULL,
+            store TEXT NOT NULL,
+            latest_version TEXT,
+            status TEXT NOT NULL,
+            timestamp INTEGER NOT NULL,
+            PRIMARY KEY (id, store)
+        )`
+	if _, err := conn.Exec(query); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create table store_listings: %w", err)
+	}
+
+	// History records when each (browser, id, profile, version) was first seen,
+	// surviving the removal of uninstalled extensions from the cache tables
+	query = `
+        CREATE TABLE IF NOT EXISTS extension_history (
+            browser TEXT NOT NULL,
+            id TEXT NOT NULL,
+            profile TEXT NOT NULL,
+            version TEXT NOT NULL,
+            first_seen INTEGER NOT NULL,
+            PRIMARY KEY (browser, id, profile, version)
+        )`
+	if _, err := conn.Exec(query); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create table extension_history: %w", err)
+	}
+
+	// Checkpoints hold per-profile results of an in-progress -resume scan
+	query = `
+        CREATE TABLE IF NOT EXISTS scan_checkpoints (
+            browser TEXT NOT NULL,
+            profile_key TEXT NOT NULL,
+            extensions TEXT NOT NULL,
+            timestamp INTEGER NOT NULL,
+            PRIMARY KEY (browser, profile_key)
+        )`
+	if _, err := conn.Exec(query); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create table scan_checkpoints: %w", err)
+	}
+
+	// Resolved names are fixed per extension version, so they outlive the
+	// per-browser cache tables and are shared across browsers
+	query = `
+        CREATE TABLE IF NOT EXISTS extension_names (
+            id TEXT NOT NULL,
+            version TEXT NOT NULL,
+            name TEXT NOT NULL,
+            short_name TEXT,
+            timestamp INTEGER NOT NULL,
+            PRIMARY KEY (id, version)
+        )`
+	if _, err := conn.Exec(query); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create table extension_names: %w", err)
 	}
 
 	return &DB{conn: conn}, nil
 }
 
+// column is a column name and its SQL definition
+type column struct {
+	name       string
+	definition string
+}
+
+// addedColumns lists columns introduced after the initial schema, so caches
+// created by older versions are upgraded in place
+var addedColumns = []column{
+	{"disabled_reason", "TEXT"},
+	{"permissions", "TEXT"},
+	{"host_permissions", "TEXT"},
+	{"path", "TEXT"},
+	{"short_name", "TEXT"},
+	{"author", "TEXT"},
+	{"homepage", "TEXT"},
+	{"rating", "REAL"},
+	{"builtin", "INTEGER NOT NULL DEFAULT 0"},
+	{"install_source", "TEXT"},
+	{"update_url", "TEXT"},
+	{"version_name", "TEXT"},
+	{"granted_permissions", "TEXT"},
+	{"granted_host_permissions", "TEXT"},
+	{"min_browser_version", "TEXT"},
+	{"incognito_allowed", "INTEGER NOT NULL DEFAULT 0"},
+	{"type", "TEXT"},
+	{"first_seen", "INTEGER"},
+	{"scan_order", "INTEGER"},
+	{"installed_by", "TEXT"},
+	{"duplicate_in_profile", "INTEGER NOT NULL DEFAULT 0"},
+	{"installed_at", "INTEGER"},
+	{"updated_at", "INTEGER"},
+}
+
+// addedScanColumns lists the columns added to cache_scans after it was
+// introduced
+var addedScanColumns = []column{
+	{"profiles", "INTEGER"},
+}
+
+// migrateColumns adds any of columns missing from an existing table
+func migrateColumns(conn *sql.DB, table string, columns []column) error {
+	rows, err := conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan table info for %s: %w", table, err)
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, col := range columns {
+		if existing[col.name] {
+			continue
+		}
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, col.name, col.definition)
+		if _, err := conn.Exec(query); err != nil {
+			return fmt.Errorf("failed to add column %s to %s: %w", col.name, table, err)
+		}
+	}
+	return nil
+}
+
+// fileDSN builds the file: URI for the database at path with the given query
+// parameters. The path is escaped so a ? or # in it isn't read as the start of
+// the query or fragment.
+func fileDSN(path, params string) string {
+	dsn := "file:" + url.PathEscape(path)
+	if params != "" {
+		dsn += "?" + params
+	}
+	return dsn
+}
+
 // Close closes the database connection
 func (d *DB) Close() error {
 	return d.conn.Close()
 }
 
-// GetExtensions retrieves cached extensions if fresh, or returns nil if stale/empty
-func (d *DB) GetExtensions(browser string) ([]browsers.Extension, error) {
-	// Check the latest timestamp
-	query := fmt.Sprintf("SELECT timestamp FROM %s_extensions ORDER BY timestamp DESC LIMIT 1", browser)
-	row := d.conn.QueryRow(query)
+// CheckWritable reports whether the database at path could be written,
+// without writing to it. An existing database is opened and its write lock
+// taken and released, which also catches a database locked by another run;
+// otherwise the directory must accept a new file, which is created and
+// removed again.
+func CheckWritable(path string) error {
+	dir := filepath.Dir(path)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		f, err := os.CreateTemp(dir, ".browser_inventory-check-")
+		if err != nil {
+			return fmt.Errorf("can't create the database in %s: %w", dir, err)
+		}
+		f.Close()
+		return os.Remove(f.Name())
+	} else if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	f.Close()
+
+	conn, err := sql.Open("sqlite3", fileDSN(path, "mode=rw"))
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer conn.Close()
+	ctx := context.Background()
+	c, err := conn.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer c.Close()
+	if _, err := c.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return fmt.Errorf("failed to lock database: %w", err)
+	}
+	_, err = c.ExecContext(ctx, "ROLLBACK")
+	return err
+}
 
+// CachedAt returns when the cached extensions for a browser were last
+// written by a scan, or the zero time if nothing is cached
+func (d *DB) CachedAt(browser string) (time.Time, error) {
 	var ts int64
-	err := row.Scan(&ts)
+	err := d.conn.QueryRow("SELECT timestamp FROM cache_scans WHERE browser = ?", browser).Scan(&ts)
 	if err == sql.ErrNoRows {
-		return nil, nil // No data yet
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query %s scan time: %w", browser, err)
+	}
+	return time.Unix(ts, 0), nil
+}
+
+// CachedProfiles returns how many profiles the scan that last wrote a
+// browser's cache read. ok is false when nothing is cached or the cache was
+// written before the count was recorded.
+func (d *DB) CachedProfiles(browser string) (profiles int, ok bool, err error) {
+	var n sql.NullInt64
+	err = d.conn.QueryRow("SELECT profiles FROM cache_scans WHERE browser = ?", browser).Scan(&n)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to query %s_extensions timestamp: %w", browser, err)
+		return 0, false, fmt.Errorf("failed to query %s profile count: %w", browser, err)
 	}
+	return int(n.Int64), n.Valid, nil
+}
 
-	if time.Since(time.Unix(ts, 0)) > 30*time.Minute {
+// DefaultCacheTTL is how long cached extensions stay fresh unless the caller
+// asks for another lifetime
+const DefaultCacheTTL = 30 * time.Minute
+
+// GetExtensions retrieves cached extensions if they were written within ttl,
+// or returns nil if stale/empty
+func (d *DB) GetExtensions(browser string, ttl time.Duration) ([]browsers.Extension, error) {
+	// The cache is as fresh as the last scan that wrote it
+	cachedAt, err := d.CachedAt(browser)
+	if err != nil {
+		return nil, err
+	}
+	if cachedAt.IsZero() {
+		return nil, nil // No data yet
+	}
+	if CacheExpired(cachedAt, ttl, time.Now()) {
 		return nil, nil // Cache is stale
 	}
 
-	// Fetch all extensions with the latest timestamp
-	query = fmt.Sprintf("SELECT id, name, browser, version, enabled, profile FROM %s_extensions WHERE timestamp = ?", browser)
-	rows, err := d.conn.Query(query, ts)
+	// The table holds exactly the extensions found by that scan, returned
+	// in the order it found them
+	query := fmt.Sprintf("SELECT id, name, browser, version, enabled, disabled_reason, profile, permissions, host_permissions, path, short_name, author, homepage, rating, builtin, install_source, update_url, version_name, granted_permissions, granted_host_permissions, min_browser_version, incognito_allowed, type, installed_by, duplicate_in_profile, installed_at, updated_at FROM %s_extensions ORDER BY scan_order", browser)
+	rows, err := d.conn.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch extensions: %w", err)
 	}
@@ -81,43 +368,345 @@ func (d *DB) GetExtensions(browser string) ([]browsers.Extension, error) {
 	for rows.Next() {
 		var e browsers.Extension
 		var enabledInt int
-		if err := rows.Scan(&e.ID, &e.Name, &e.Browser, &e.Version, &enabledInt, &e.Profile); err != nil {
+		var disabledReason, permissions, hostPermissions, path, shortName, author, homepage, installSource, updateURL, versionName, grantedPermissions, grantedHostPermissions, minBrowserVersion, addonType, installedBy sql.NullString
+		var rating sql.NullFloat64
+		var installedAt, updatedAt sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.Name, &e.Browser, &e.Version, &enabledInt, &disabledReason, &e.Profile, &permissions, &hostPermissions, &path, &shortName, &author, &homepage, &rating, &e.Builtin, &installSource, &updateURL, &versionName, &grantedPermissions, &grantedHostPermissions, &minBrowserVersion, &e.IncognitoAllowed, &addonType, &installedBy, &e.DuplicateInProfile, &installedAt, &updatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 		e.Enabled = enabledInt != 0
+		e.DisabledReason = disabledReason.String
+		e.Permissions = decodeList(permissions)
+		e.HostPermissions = decodeList(hostPermissions)
+		e.Path = path.String
+		e.ShortName = shortName.String
+		e.Author = author.String
+		e.Homepage = homepage.String
+		e.Rating = rating.Float64
+		e.InstallSource = installSource.String
+		e.UpdateURL = updateURL.String
+		e.VersionName = versionName.String
+		e.GrantedPermissions = decodeList(grantedPermissions)
+		e.GrantedHostPermissions = decodeList(grantedHostPermissions)
+		e.MinBrowserVersion = minBrowserVersion.String
+		e.Type = addonType.String
+		e.InstalledBy = installedBy.String
+		e.InstalledAt = decodeTime(installedAt)
+		e.UpdatedAt = decodeTime(updatedAt)
 		extensions = append(extensions, e)
 	}
 
 	return extensions, nil
 }
 
-// UpdateExtensions updates the extension table for a browser
-func (d *DB) UpdateExtensions(browser string, extensions []browsers.Extension) error {
+// CacheExpired reports whether a cache written at cachedAt is stale at now
+// under ttl. A cache exactly ttl old is still fresh.
+func CacheExpired(cachedAt time.Time, ttl time.Duration, now time.Time) bool {
+	return now.Sub(cachedAt) > ttl
+}
+
+// extensionColumns are the per-browser cache columns written from an
+// Extension, in the order UpdateExtensions binds them. The key columns come
+// first.
+var extensionColumns = []string{
+	"id", "profile", "version",
+	"name", "browser", "enabled", "disabled_reason", "permissions", "host_permissions", "path", "short_name", "author", "homepage", "rating", "builtin", "install_source", "update_url", "version_name", "granted_permissions", "granted_host_permissions", "min_browser_version", "incognito_allowed", "type", "installed_by", "duplicate_in_profile", "installed_at", "updated_at",
+}
+
+// extensionKeyColumns is how many leading extensionColumns form the key
+const extensionKeyColumns = 3
+
+// upsertQuery builds the UpdateExtensions statement for a browser's table. A
+// new extension is inserted with first_seen and timestamp set to the scan
+// time. An existing one is only rewritten when one of its columns changed,
+// getting a new timestamp, or when it moved in the scan order, keeping its
+// timestamp; first_seen is kept (and filled in for rows cached before the
+// column existed).
+func upsertQuery(browser string) string {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(extensionColumns)+3), ", ")
+	var set, changed []string
+	for _, col := range extensionColumns[extensionKeyColumns:] {
+		set = append(set, fmt.Sprintf("%s = excluded.%s", col, col))
+		changed = append(changed, fmt.Sprintf("%s IS NOT excluded.%s", col, col))
+	}
+	contentChanged := "(" + strings.Join(changed, " OR ") + ")"
+	set = append(set,
+		"first_seen = COALESCE(first_seen, excluded.first_seen)",
+		"scan_order = excluded.scan_order",
+		fmt.Sprintf("timestamp = CASE WHEN %s THEN excluded.timestamp ELSE timestamp END", contentChanged),
+	)
+	return fmt.Sprintf("INSERT INTO %s_extensions (%s, first_seen, scan_order, timestamp) VALUES (%s) ON CONFLICT (id, profile, version) DO UPDATE SET %s WHERE %s OR scan_order IS NOT excluded.scan_order OR first_seen IS NULL",
+		browser, strings.Join(extensionColumns, ", "), placeholders, strings.Join(set, ", "), contentChanged)
+}
+
+// UpdateExtensions replaces the cached extensions for a browser with the
+// result of a scan. Rows are upserted by (id, profile, version): unchanged
+// extensions aren't rewritten and keep their timestamp and first_seen,
+// changed ones get a new timestamp, and extensions no longer found are
+// removed. The scan time is recorded for CachedAt, and the number of profiles
+// it read for CachedProfiles.
+func (d *DB) UpdateExtensions(browser string, extensions []browsers.Extension, profiles int) error {
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+
 	tx, err := d.conn.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
-	// Clear old data
-	query := fmt.Sprintf("DELETE FROM %s_extensions", browser)
-	if _, err := tx.Exec(query); err != nil {
+	existing, err := cachedKeys(tx, browser)
+	if err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to clear %s_extensions: %w", browser, err)
+		return err
 	}
 
-	// Insert new data with composite key
-	query = fmt.Sprintf("INSERT INTO %s_extensions (id, name, browser, version, enabled, profile, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?)", browser)
+	query := upsertQuery(browser)
+	historyQuery := "INSERT OR IGNORE INTO extension_history (browser, id, profile, version, first_seen) VALUES (?, ?, ?, ?, ?)"
 	now := time.Now().Unix()
-	for _, ext := range extensions {
+	for i, ext := range extensions {
 		enabledInt := 0
 		if ext.Enabled {
 			enabledInt = 1
 		}
-		if _, err := tx.Exec(query, ext.ID, ext.Name, ext.Browser, ext.Version, enabledInt, ext.Profile, now); err != nil {
+		if _, err := tx.Exec(query, ext.ID, ext.Profile, ext.Version, ext.Name, ext.Browser, enabledInt, ext.DisabledReason, encodeList(ext.Permissions), encodeList(ext.HostPermissions), ext.Path, ext.ShortName, ext.Author, ext.Homepage, ext.Rating, ext.Builtin, ext.InstallSource, ext.UpdateURL, ext.VersionName, encodeList(ext.GrantedPermissions), encodeList(ext.GrantedHostPermissions), ext.MinBrowserVersion, ext.IncognitoAllowed, ext.Type, ext.InstalledBy, ext.DuplicateInProfile, encodeTime(ext.InstalledAt), encodeTime(ext.UpdatedAt), now, i, now); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to upsert extension: %w", err)
+		}
+		delete(existing, HistoryKey(ext.ID, ext.Profile, ext.Version))
+		if _, err := tx.Exec(historyQuery, browser, ext.ID, ext.Profile, ext.Version, now); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record extension history: %w", err)
+		}
+	}
+
+	// Whatever the scan didn't find was uninstalled or updated away
+	query = fmt.Sprintf("DELETE FROM %s_extensions WHERE id = ? AND profile = ? AND version = ?", browser)
+	for _, k := range existing {
+		if _, err := tx.Exec(query, k.id, k.profile, k.version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to remove extension: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec("INSERT OR REPLACE INTO cache_scans (browser, timestamp, profiles) VALUES (?, ?, ?)", browser, now, profiles); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record %s scan time: %w", browser, err)
+	}
+
+	return tx.Commit()
+}
+
+// cacheKey is the primary key of a per-browser cache row
+type cacheKey struct {
+	id, profile, version string
+}
+
+// cachedKeys returns the keys of a browser's cached rows, indexed by HistoryKey
+func cachedKeys(tx *sql.Tx, browser string) (map[string]cacheKey, error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT id, profile, version FROM %s_extensions", browser))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s_extensions: %w", browser, err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string]cacheKey)
+	for rows.Next() {
+		var id, profile, version sql.NullString
+		if err := rows.Scan(&id, &profile, &version); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		k := cacheKey{id.String, profile.String, version.String}
+		keys[HistoryKey(k.id, k.profile, k.version)] = k
+	}
+	return keys, rows.Err()
+}
+
+// encodeList stores a string list as a JSON array column
+func encodeList(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(list)
+	return string(data)
+}
+
+// encodeTime stores an optional time as Unix microseconds, or NULL
+func encodeTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UnixMicro()
+}
+
+// decodeTime reverses encodeTime
+func decodeTime(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := time.UnixMicro(v.Int64).UTC()
+	return &t
+}
+
+// decodeList reads a string list stored by encodeList
+func decodeList(s sql.NullString) []string {
+	if s.String == "" {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal([]byte(s.String), &list); err != nil {
+		return nil
+	}
+	return list
+}
+
+// FirstSeen returns when each extension version was first recorded for a
+// browser, keyed by HistoryKey
+func (d *DB) FirstSeen(browser string) (map[string]time.Time, error) {
+	rows, err := d.conn.Query("SELECT id, profile, version, first_seen FROM extension_history WHERE browser = ?", browser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query extension history: %w", err)
+	}
+	defer rows.Close()
+
+	firstSeen := make(map[string]time.Time)
+	for rows.Next() {
+		var id, profile, version string
+		var ts int64
+		if err := rows.Scan(&id, &profile, &version, &ts); err != nil {
+			return nil, fmt.Errorf("failed to scan history row: %w", err)
+		}
+		firstSeen[HistoryKey(id, profile, version)] = time.Unix(ts, 0)
+	}
+	return firstSeen, rows.Err()
+}
+
+// HistoryKey builds the lookup key used by FirstSeen
+func HistoryKey(id, profile, version string) string {
+	return id + "\x00" + profile + "\x00" + version
+}
+
+// storeListingTTL is how long a cached store listing stays fresh
+const storeListingTTL = 24 * time.Hour
+
+// GetStoreListing returns a fresh cached store listing, or false if none is cached
+func (d *DB) GetStoreListing(store, id string) (webstore.Listing, bool, error) {
+	row := d.conn.QueryRow("SELECT latest_version, status, timestamp FROM store_listings WHERE id = ? AND store = ?", id, store)
+
+	listing := webstore.Listing{ID: id}
+	var latestVersion sql.NullString
+	var ts int64
+	err := row.Scan(&latestVersion, &listing.Status, &ts)
+	if err == sql.ErrNoRows {
+		return webstore.Listing{}, false, nil
+	}
+	if err != nil {
+		return webstore.Listing{}, false, fmt.Errorf("failed to query store listing for %s: %w", id, err)
+	}
+	if time.Since(time.Unix(ts, 0)) > storeListingTTL {
+		return webstore.Listing{}, false, nil // Cache is stale
+	}
+	listing.LatestVersion = latestVersion.String
+	return listing, true, nil
+}
+
+// UpdateStoreListings caches store listings for the given store
+func (d *DB) UpdateStoreListings(store string, listings map[string]webstore.Listing) error {
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	query := "INSERT OR REPLACE INTO store_listings (id, store, latest_version, status, timestamp) VALUES (?, ?, ?, ?, ?)"
+	now := time.Now().Unix()
+	for _, listing := range listings {
+		if _, err := tx.Exec(query, listing.ID, store, listing.LatestVersion, listing.Status, now); err != nil {
 			tx.Rollback()
-			return fmt.Errorf("failed to insert extension: %w", err)
+			return fmt.Errorf("failed to insert store listing: %w", err)
 		}
 	}
 
 	return tx.Commit()
 }
+
+// checkpointTTL bounds how long an interrupted scan can be resumed
+const checkpointTTL = 24 * time.Hour
+
+// LoadCheckpoint returns the saved results for a profile of an interrupted scan
+func (d *DB) LoadCheckpoint(browser, profileKey string) ([]browsers.Extension, bool, error) {
+	row := d.conn.QueryRow("SELECT extensions, timestamp FROM scan_checkpoints WHERE browser = ? AND profile_key = ?", browser, profileKey)
+
+	var data string
+	var ts int64
+	err := row.Scan(&data, &ts)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query checkpoint: %w", err)
+	}
+	if time.Since(time.Unix(ts, 0)) > checkpointTTL {
+		return nil, false, nil // Too old to trust
+	}
+
+	var extensions []browsers.Extension
+	if err := json.Unmarshal([]byte(data), &extensions); err != nil {
+		return nil, false, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	return extensions, true, nil
+}
+
+// SaveCheckpoint records the results of a finished profile
+func (d *DB) SaveCheckpoint(browser, profileKey string, extensions []browsers.Extension) error {
+	data, err := json.Marshal(extensions)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+	query := "INSERT OR REPLACE INTO scan_checkpoints (browser, profile_key, extensions, timestamp) VALUES (?, ?, ?, ?)"
+	if _, err := d.conn.Exec(query, browser, profileKey, string(data), time.Now().Unix()); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// ClearCheckpoints removes a browser's checkpoints once its scan completes
+func (d *DB) ClearCheckpoints(browser string) error {
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+	if _, err := d.conn.Exec("DELETE FROM scan_checkpoints WHERE browser = ?", browser); err != nil {
+		return fmt.Errorf("failed to clear checkpoints: %w", err)
+	}
+	return nil
+}
+
+// LookupNames returns the resolved names cached for an extension version
+func (d *DB) LookupNames(id, version string) (string, string, bool, error) {
+	row := d.conn.QueryRow("SELECT name, short_name FROM extension_names WHERE id = ? AND version = ?", id, version)
+
+	var name string
+	var shortName sql.NullString
+	err := row.Scan(&name, &shortName)
+	if err == sql.ErrNoRows {
+		return "", "", false, nil
+	}
+	if err != nil {
+		return "", "", false, fmt.Errorf("failed to query cached names: %w", err)
+	}
+	return name, shortName.String, true, nil
+}
+
+// SaveNames caches the resolved names of an extension version. Entries are
+// keyed by version, so an update is resolved afresh.
+func (d *DB) SaveNames(id, version, name, shortName string) error {
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+	query := "INSERT OR REPLACE INTO extension_names (id, version, name, short_name, timestamp) VALUES (?, ?, ?, ?, ?)"
+	if _, err := d.conn.Exec(query, id, version, name, shortName, time.Now().Unix()); err != nil {
+		return fmt.Errorf("failed to cache names: %w", err)
+	}
+	return nil
+}
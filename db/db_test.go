@@ -0,0 +1,383 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go-browser-inventory/internal/browsers"
+)
+
+var testBrowsers = []string{"Chrome", "Edge", "Firefox"}
+
+// newTestDB opens a fresh cache database in a temporary directory
+func newTestDB(t *testing.T) (*DB, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	d, err := NewDB(path, testBrowsers)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d, path
+}
+
+// testExtensions returns n extensions of browser, all at version
+func testExtensions(browser, version string, n int) []browsers.Extension {
+	exts := make([]browsers.Extension, n)
+	for i := range exts {
+		exts[i] = browsers.Extension{
+			ID:          fmt.Sprintf("ext%02d", i),
+			Name:        fmt.Sprintf("Extension %d", i),
+			Browser:     browser,
+			Version:     version,
+			Enabled:     true,
+			Profile:     "Default",
+			Permissions: []string{"storage"},
+		}
+	}
+	return exts
+}
+
+func TestNewDBEscapesPath(t *testing.T) {
+	for _, name := range []string{"cache?mode=ro.db", "cache#1.db", "cache 100%.db"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			d, err := NewDB(path, testBrowsers)
+			if err != nil {
+				t.Fatalf("NewDB: %v", err)
+			}
+			defer d.Close()
+			if err := d.UpdateExtensions("Chrome", testExtensions("Chrome", "1.0", 1), 1); err != nil {
+				t.Fatalf("UpdateExtensions: %v", err)
+			}
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("database not created at %s: %v", path, err)
+			}
+			if err := CheckWritable(path); err != nil {
+				t.Errorf("CheckWritable: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewDBRejectsBrowserNames(t *testing.T) {
+	for _, name := range []string{"Brave Browser", "Chrome; DROP TABLE cache_scans", "Opera-GX", "1Browser", ""} {
+		path := filepath.Join(t.TempDir(), "cache.db")
+		if d, err := NewDB(path, []string{"Chrome", name}); err == nil {
+			d.Close()
+			t.Errorf("NewDB accepted browser name %q", name)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("NewDB created a database for browser name %q", name)
+		}
+	}
+}
+
+// TestConcurrentAccess hammers one database from many goroutines, through a
+// shared DB and a second connection like another process would open. Run it
+// with -race.
+func TestConcurrentAccess(t *testing.T) {
+	d, path := newTestDB(t)
+	other, err := NewDB(path, testBrowsers)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	defer other.Close()
+
+	const (
+		workers    = 16
+		iterations = 25
+		perScan    = 20
+	)
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*iterations*2)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			conn := d
+			if w%4 == 0 {
+				conn = other
+			}
+			browser := testBrowsers[w%len(testBrowsers)]
+			for i := 0; i < iterations; i++ {
+				version := fmt.Sprintf("%d.%d", w, i)
+				if err := conn.UpdateExtensions(browser, testExtensions(browser, version, perScan), 1); err != nil {
+					errs <- fmt.Errorf("worker %d UpdateExtensions: %w", w, err)
+					return
+				}
+				exts, err := conn.GetExtensions(browser, time.Hour)
+				if err != nil {
+					errs <- fmt.Errorf("worker %d GetExtensions: %w", w, err)
+					return
+				}
+				// Each scan replaces the cache whole, so a read sees exactly
+				// one scan's extensions, never a mix
+				if len(exts) != perScan {
+					errs <- fmt.Errorf("worker %d read %d extensions, want %d", w, len(exts), perScan)
+					return
+				}
+				for _, e := range exts[1:] {
+					if e.Version != exts[0].Version {
+						errs <- fmt.Errorf("worker %d read a mix of versions %s and %s", w, exts[0].Version, e.Version)
+						return
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// rowTimes returns the first_seen and timestamp of each cached row of browser
+// by extension ID
+func rowTimes(t *testing.T, d *DB, browser string) map[string][2]int64 {
+	t.Helper()
+	rows, err := d.conn.Query(fmt.Sprintf("SELECT id, first_seen, timestamp FROM %s_extensions", browser))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	times := make(map[string][2]int64)
+	for rows.Next() {
+		var id string
+		var firstSeen, timestamp int64
+		if err := rows.Scan(&id, &firstSeen, &timestamp); err != nil {
+			t.Fatal(err)
+		}
+		times[id] = [2]int64{firstSeen, timestamp}
+	}
+	return times
+}
+
+func TestUpdateExtensionsUpsert(t *testing.T) {
+	d, _ := newTestDB(t)
+	exts := testExtensions("Chrome", "1.0", 3)
+	if err := d.UpdateExtensions("Chrome", exts, 1); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	inserted := rowTimes(t, d, "Chrome")
+	if len(inserted) != 3 {
+		t.Fatalf("inserted %d rows, want 3", len(inserted))
+	}
+	for id, times := range inserted {
+		if times[0] == 0 || times[0] != times[1] {
+			t.Errorf("%s: first_seen %d, timestamp %d; want both set to the scan time", id, times[0], times[1])
+		}
+	}
+
+	// Age the rows so a rewrite would be visible within the same second
+	const aged = 1000
+	if _, err := d.conn.Exec("UPDATE Chrome_extensions SET first_seen = ?, timestamp = ?", aged, aged); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same scan again: nothing is rewritten
+	if err := d.UpdateExtensions("Chrome", exts, 1); err != nil {
+		t.Fatalf("no-op: %v", err)
+	}
+	for id, times := range rowTimes(t, d, "Chrome") {
+		if times != [2]int64{aged, aged} {
+			t.Errorf("no-op %s: first_seen %d, timestamp %d; want both kept at %d", id, times[0], times[1], aged)
+		}
+	}
+
+	// ext00 changes, ext01 is unchanged, ext02 is gone
+	next := []browsers.Extension{exts[0], exts[1]}
+	next[0].Enabled = false
+	next[0].DisabledReason = "user"
+	if err := d.UpdateExtensions("Chrome", next, 1); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	updated := rowTimes(t, d, "Chrome")
+	if times := updated["ext00"]; times[0] != aged || times[1] <= aged {
+		t.Errorf("changed row: first_seen %d, timestamp %d; want first_seen %d and a new timestamp", times[0], times[1], aged)
+	}
+	if times := updated["ext01"]; times != [2]int64{aged, aged} {
+		t.Errorf("unchanged row: first_seen %d, timestamp %d; want both kept at %d", times[0], times[1], aged)
+	}
+	if _, ok := updated["ext02"]; ok {
+		t.Error("removed extension is still cached")
+	}
+
+	cached, err := d.GetExtensions("Chrome", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cached) != 2 || cached[0].Enabled || cached[0].DisabledReason != "user" {
+		t.Errorf("GetExtensions = %+v, want the updated ext00 and ext01", cached)
+	}
+}
+
+// writeLocalizedExtension writes a Chrome Default profile under home with one
+// extension whose name is resolved from _locales
+func writeLocalizedExtension(t *testing.T, home, id string) (localesDir string) {
+	t.Helper()
+	dir := filepath.Join(home, ".config", "google-chrome", "Default", "Extensions", id, "1.0_0")
+	files := map[string]string{
+		"manifest.json":             `{"manifest_version": 3, "name": "__MSG_extName__", "version": "1.0", "default_locale": "en"}`,
+		"_locales/en/messages.json": `{"extName": {"message": "Localized Name"}}`,
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "_locales")
+}
+
+// TestNameCacheSecondRun checks that a second scan takes the resolved name
+// from the database instead of the locale files
+func TestNameCacheSecondRun(t *testing.T) {
+	d, _ := newTestDB(t)
+	home := t.TempDir()
+	const id = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	localesDir := writeLocalizedExtension(t, home, id)
+
+	scan := func() browsers.Extension {
+		t.Helper()
+		bi := browsers.NewBrowserInventory()
+		bi.Options.HomeDir = home
+		bi.Options.NameCache = d
+		exts, err := bi.GetExtensions("Chrome", false)
+		if err != nil {
+			t.Fatalf("GetExtensions: %v", err)
+		}
+		if len(exts) != 1 {
+			t.Fatalf("got %d extensions, want 1", len(exts))
+		}
+		return exts[0]
+	}
+
+	if got := scan().Name; got != "Localized Name" {
+		t.Fatalf("first run name = %q, want it resolved from _locales", got)
+	}
+	if _, _, ok, err := d.LookupNames(id, "1.0"); !ok || err != nil {
+		t.Fatalf("name not cached after the first run (err %v)", err)
+	}
+
+	// Without the locale files only the cache can supply the name
+	if err := os.RemoveAll(localesDir); err != nil {
+		t.Fatal(err)
+	}
+	if got := scan().Name; got != "Localized Name" {
+		t.Errorf("second run name = %q, want the cached name", got)
+	}
+}
+
+// TestGetExtensionsTTL ages each browser's cache differently and reads it
+// back with a per-browser lifetime, as -cache-ttl browser=duration does
+func TestGetExtensionsTTL(t *testing.T) {
+	d, _ := newTestDB(t)
+	ages := map[string]time.Duration{
+		"Chrome":  10 * time.Minute,
+		"Edge":    2 * time.Hour,
+		"Firefox": 25 * time.Hour,
+	}
+	for browser, age := range ages {
+		if err := d.UpdateExtensions(browser, testExtensions(browser, "1.0", 2), 1); err != nil {
+			t.Fatalf("UpdateExtensions(%s): %v", browser, err)
+		}
+		if _, err := d.conn.Exec("UPDATE cache_scans SET timestamp = ? WHERE browser = ?", time.Now().Add(-age).Unix(), browser); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		browser   string
+		ttl       time.Duration
+		wantFresh bool
+	}{
+		{"Chrome", time.Hour, true},
+		{"Chrome", 5 * time.Minute, false},
+		{"Edge", 3 * time.Hour, true},
+		{"Edge", time.Hour, false},
+		{"Firefox", 48 * time.Hour, true},
+		{"Firefox", 24 * time.Hour, false},
+		{"Firefox", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%v", tt.browser, tt.ttl), func(t *testing.T) {
+			exts, err := d.GetExtensions(tt.browser, tt.ttl)
+			if err != nil {
+				t.Fatalf("GetExtensions: %v", err)
+			}
+			if fresh := exts != nil; fresh != tt.wantFresh {
+				t.Fatalf("GetExtensions(%s, %v) returned %d extensions, want fresh=%v", tt.browser, tt.ttl, len(exts), tt.wantFresh)
+			}
+			for _, ext := range exts {
+				if ext.Browser != tt.browser {
+					t.Errorf("GetExtensions(%s) returned a %s extension", tt.browser, ext.Browser)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheExpired(t *testing.T) {
+	now := time.Date(2024, 1, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		age  time.Duration
+		ttl  time.Duration
+		want bool
+	}{
+		{0, 0, false},
+		{time.Second, 0, true},
+		{59 * time.Minute, time.Hour, false},
+		{time.Hour, time.Hour, false},
+		{time.Hour + time.Second, time.Hour, true},
+		{-time.Hour, time.Minute, false},
+	}
+	for _, tt := range tests {
+		if got := CacheExpired(now.Add(-tt.age), tt.ttl, now); got != tt.want {
+			t.Errorf("CacheExpired(age %v, ttl %v) = %v, want %v", tt.age, tt.ttl, got, tt.want)
+		}
+	}
+}
+
+func TestCheckpoints(t *testing.T) {
+	d, _ := newTestDB(t)
+	const key = "/home/user/.config/google-chrome/Default"
+	for _, b := range []string{"Chrome", "Edge"} {
+		if err := d.SaveCheckpoint(b, key, testExtensions(b, "1.0", 2)); err != nil {
+			t.Fatalf("SaveCheckpoint(%s): %v", b, err)
+		}
+	}
+
+	exts, ok, err := d.LoadCheckpoint("Chrome", key)
+	if err != nil || !ok || len(exts) != 2 || exts[0].Browser != "Chrome" {
+		t.Fatalf("LoadCheckpoint = %d extensions, %v, %v; want Chrome's 2", len(exts), ok, err)
+	}
+	if _, ok, _ := d.LoadCheckpoint("Chrome", key+"?lenient"); ok {
+		t.Error("LoadCheckpoint matched a key saved with other options")
+	}
+
+	if err := d.ClearCheckpoints("Chrome"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := d.LoadCheckpoint("Chrome", key); ok {
+		t.Error("Chrome checkpoint survived ClearCheckpoints")
+	}
+	if _, ok, _ := d.LoadCheckpoint("Edge", key); !ok {
+		t.Error("ClearCheckpoints(Chrome) removed Edge's checkpoint")
+	}
+
+	if _, err := d.conn.Exec("UPDATE scan_checkpoints SET timestamp = ?", time.Now().Add(-checkpointTTL-time.Minute).Unix()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := d.LoadCheckpoint("Edge", key); ok {
+		t.Error("LoadCheckpoint returned an expired checkpoint")
+	}
+}
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-browser-inventory/internal/browsers"
+)
+
+// SnapshotSchemaVersion is recorded in an exported snapshot's snapshot table.
+// It changes only when the snapshot schema changes incompatibly, which is
+// independent of the cache schema.
+const SnapshotSchemaVersion = 1
+
+// snapshotSchema is the -export-db format, documented in the README. Times are
+// RFC 3339 UTC text, booleans 0/1, and fields an extension doesn't have NULL.
+const snapshotSchema = `
+CREATE TABLE snapshot (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
+
+CREATE TABLE extensions (
+    extension_key INTEGER PRIMARY KEY,
+    browser TEXT NOT NULL,
+    user TEXT,
+    profile TEXT,
+    id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    short_name TEXT,
+    friendly_name TEXT,
+    version TEXT NOT NULL,
+    version_name TEXT,
+    type TEXT,
+    enabled INTEGER NOT NULL,
+    disabled_reason TEXT,
+    builtin INTEGER NOT NULL,
+    install_source TEXT,
+    installed_by TEXT,
+    author TEXT,
+    homepage TEXT,
+    update_url TEXT,
+    min_browser_version TEXT,
+    incognito_allowed INTEGER NOT NULL,
+    path TEXT,
+    source_path TEXT,
+    installed_at TEXT,
+    updated_at TEXT,
+    first_seen TEXT,
+    rating REAL,
+    store_latest_version TEXT,
+    store_status TEXT,
+    known_status TEXT,
+    risk TEXT,
+    marked_for_deletion INTEGER NOT NULL,
+    duplicate_in_profile INTEGER NOT NULL,
+    computed_id TEXT,
+    id_mismatch INTEGER NOT NULL,
+    verified INTEGER,
+    from_cache INTEGER NOT NULL
+);
+CREATE INDEX extensions_id ON extensions (id);
+
+CREATE TABLE permissions (
+    extension_key INTEGER NOT NULL REFERENCES extensions (extension_key),
+    permission TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    requested INTEGER NOT NULL,
+    granted INTEGER NOT NULL,
+    PRIMARY KEY (extension_key, kind, permission)
+);
+CREATE INDEX permissions_permission ON permissions (permission);
+
+CREATE TABLE modified_files (
+    extension_key INTEGER NOT NULL REFERENCES extensions (extension_key),
+    file TEXT NOT NULL
+);
+
+CREATE TABLE merged_profiles (
+    extension_key INTEGER NOT NULL REFERENCES extensions (extension_key),
+    profile TEXT NOT NULL
+);
+`
+
+// ExportSnapshot writes extensions to a new SQLite database at path in the
+// documented snapshot schema, for ad-hoc SQL analysis. info is stored in the
+// snapshot table next to schema_version. The file is written under a
+// temporary name and renamed into place, replacing any existing file.
+func ExportSnapshot(path string, extensions []browsers.Extension, info map[string]string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	if err := writeSnapshot(tmpPath, extensions, info); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// writeSnapshot fills the empty database file at path
+func writeSnapshot(path string, extensions []browsers.Extension, info map[string]string) error {
+	conn, err := sql.Open("sqlite3", fileDSN(path, ""))
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer conn.Close()
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(snapshotSchema); err != nil {
+		return fmt.Errorf("failed to create snapshot schema: %w", err)
+	}
+
+	infoQuery := "INSERT INTO snapshot (key, value) VALUES (?, ?)"
+	if _, err := tx.Exec(infoQuery, "schema_version", fmt.Sprint(SnapshotSchemaVersion)); err != nil {
+		return fmt.Errorf("failed to write snapshot info: %w", err)
+	}
+	for key, value := range info {
+		if _, err := tx.Exec(infoQuery, key, value); err != nil {
+			return fmt.Errorf("failed to write snapshot info: %w", err)
+		}
+	}
+
+	extQuery := `INSERT INTO extensions (browser, user, profile, id, name, short_name, friendly_name, version, version_name, type, enabled, disabled_reason, builtin, install_source, installed_by, author, homepage, update_url, min_browser_version, incognito_allowed, path, source_path, installed_at, updated_at, first_seen, rating, store_latest_version, store_status, known_status, risk, marked_for_deletion, duplicate_in_profile, computed_id, id_mismatch, verified, from_cache)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	for _, ext := range extensions {
+		var rating any
+		if ext.Rating != 0 {
+			rating = ext.Rating
+		}
+		res, err := tx.Exec(extQuery, ext.Browser, nullable(ext.User), nullable(ext.Profile), ext.ID, ext.Name, nullable(ext.ShortName), nullable(ext.FriendlyName), ext.Version, nullable(ext.VersionName), nullable(ext.Type), ext.Enabled, nullable(ext.DisabledReason), ext.Builtin, nullable(ext.InstallSource), nullable(ext.InstalledBy), nullable(ext.Author), nullable(ext.Homepage), nullable(ext.UpdateURL), nullable(ext.MinBrowserVersion), ext.IncognitoAllowed, nullable(ext.Path), nullable(ext.SourcePath), snapshotTime(ext.InstalledAt), snapshotTime(ext.UpdatedAt), snapshotTime(ext.FirstSeen), rating, nullable(ext.StoreLatestVersion), nullable(ext.StoreStatus), nullable(ext.KnownStatus), nullable(ext.Risk), ext.MarkedForDeletion, ext.DuplicateInProfile, nullable(ext.ComputedID), ext.IDMismatch, ext.Verified, ext.FromCache)
+		if err != nil {
+			return fmt.Errorf("failed to write extension %s: %w", ext.ID, err)
+		}
+		key, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to write extension %s: %w", ext.ID, err)
+		}
+
+		if err := writeSnapshotPermissions(tx, key, "permission", ext.Permissions, ext.GrantedPermissions); err != nil {
+			return err
+		}
+		if err := writeSnapshotPermissions(tx, key, "host", ext.HostPermissions, ext.GrantedHostPermissions); err != nil {
+			return err
+		}
+		for _, file := range ext.ModifiedFiles {
+			if _, err := tx.Exec("INSERT INTO modified_files (extension_key, file) VALUES (?, ?)", key, file); err != nil {
+				return fmt.Errorf("failed to write modified file: %w", err)
+			}
+		}
+		for _, profile := range ext.Profiles {
+			if _, err := tx.Exec("INSERT INTO merged_profiles (extension_key, profile) VALUES (?, ?)", key, profile); err != nil {
+				return fmt.Errorf("failed to write merged profile: %w", err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// writeSnapshotPermissions writes one row per permission of the given kind
+// that the extension requests, was granted, or both
+func writeSnapshotPermissions(tx *sql.Tx, key int64, kind string, requested, granted []string) error {
+	query := `INSERT INTO permissions (extension_key, permission, kind, requested, granted) VALUES (?, ?, ?, ?, ?)
+        ON CONFLICT (extension_key, kind, permission) DO UPDATE SET requested = requested OR excluded.requested, granted = granted OR excluded.granted`
+	for _, p := range requested {
+		if _, err := tx.Exec(query, key, p, kind, true, false); err != nil {
+			return fmt.Errorf("failed to write permission: %w", err)
+		}
+	}
+	for _, p := range granted {
+		if _, err := tx.Exec(query, key, p, kind, false, true); err != nil {
+			return fmt.Errorf("failed to write permission: %w", err)
+		}
+	}
+	return nil
+}
+
+// nullable stores an empty string as NULL
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// snapshotTime stores an optional time as RFC 3339 UTC text, or NULL
+func snapshotTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	"go-browser-inventory/db"
+	"go-browser-inventory/internal/browsers"
+)
+
+// runDoctor prints the -doctor checklist for the selected browsers and the
+// cache database, returning exitError when any check failed. Nothing is
+// scanned or written.
+func runDoctor(w io.Writer, bi *browsers.BrowserInventory, browserList []string) int {
+	checks := bi.Diagnose(browserList)
+
+	cache := browsers.DoctorCheck{Label: "Cache database", Path: dbPath, Status: browsers.CheckPass}
+	if err := db.CheckWritable(dbPath); err != nil {
+		cache.Status, cache.Detail = browsers.CheckFail, err.Error()
+	}
+	checks = append(checks, cache)
+
+	fmt.Fprintf(w, "go-browser-inventory %s on %s/%s\n", version, runtime.GOOS, runtime.GOARCH)
+	counts := make(map[string]int)
+	for _, c := range checks {
+		counts[c.Status]++
+		label := c.Label
+		if c.Browser != "" {
+			label = c.Browser + ": " + label
+		}
+		line := fmt.Sprintf("[%s] %s", strings.ToUpper(c.Status), label)
+		if c.Path != "" {
+			line += ": " + c.Path
+		}
+		if c.Detail != "" {
+			line += " (" + c.Detail + ")"
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "%d passed, %d failed, %d skipped\n", counts[browsers.CheckPass], counts[browsers.CheckFail], counts[browsers.CheckSkip])
+
+	if counts[browsers.CheckFail] > 0 {
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go-browser-inventory/db"
+	"go-browser-inventory/internal/browsers"
+	"go-browser-inventory/internal/webstore"
+)
+
+// enrichFromStore populates store fields for extensions installed from a
+// configured store, serving listings from the DB cache where fresh and looking
+// up the rest. A nil dbConn looks everything up.
+func enrichFromStore(dbConn *db.DB, bi *browsers.BrowserInventory, client *webstore.Client, extensions []browsers.Extension, debug bool) {
+	// Group extension indexes by the store that serves them
+	byStore := make(map[string][]int)
+	for i, ext := range extensions {
+		if store := extensionStore(bi, ext); store != "" {
+			byStore[store] = append(byStore[store], i)
+		}
+	}
+
+	for store, indexes := range byStore {
+		listings := make(map[string]webstore.Listing)
+		var missing []string
+		for _, i := range indexes {
+			id := extensions[i].ID
+			if _, done := listings[id]; done || contains(missing, id) {
+				continue
+			}
+			if dbConn != nil {
+				listing, ok, err := dbConn.GetStoreListing(store, id)
+				if err != nil && debug {
+					fmt.Fprintf(os.Stderr, "Error retrieving cached store listing for %s: %v\n", id, err)
+				}
+				if ok {
+					listings[id] = listing
+					continue
+				}
+			}
+			missing = append(missing, id)
+		}
+
+		if len(missing) > 0 {
+			fetched, err := client.Lookup(context.Background(), store, missing)
+			if err != nil && debug {
+				fmt.Fprintf(os.Stderr, "Error looking up store listings at %s: %v\n", store, err)
+			}
+			if dbConn != nil {
+				if err := dbConn.UpdateStoreListings(store, fetched); err != nil && debug {
+					fmt.Fprintf(os.Stderr, "Error caching store listings: %v\n", err)
+				}
+			}
+			for id, listing := range fetched {
+				listings[id] = listing
+			}
+		}
+
+		for _, i := range indexes {
+			if listing, ok := listings[extensions[i].ID]; ok {
+				extensions[i].StoreStatus = listing.Status
+				extensions[i].StoreLatestVersion = listing.LatestVersion
+			}
+		}
+	}
+}
+
+// extensionStore returns the configured store whose update service the
+// extension updates from, or "" when it didn't come from one. The store is
+// taken from the extension's own update_url, not its browser's: a Chrome Web
+// Store extension installed in Edge is only known to the Chrome Web Store,
+// and asking any store about unpacked, preinstalled, self-hosted, or
+// component extensions would report them as removed.
+func extensionStore(bi *browsers.BrowserInventory, ext browsers.Extension) string {
+	if ext.Builtin || ext.InstallSource != "" || ext.UpdateURL == "" {
+		return ""
+	}
+	for _, name := range bi.BrowserNames() {
+		config, _ := bi.Config(name)
+		if config.StoreUpdateURL != "" && sameUpdateURL(ext.UpdateURL, config.StoreUpdateURL) {
+			return config.StoreUpdateURL
+		}
+	}
+	return ""
+}
+
+// sameUpdateURL compares update URLs ignoring scheme, host case, and a
+// trailing slash, as manifests written by older store versions differ in those
+func sameUpdateURL(a, b string) bool {
+	normalize := func(s string) string {
+		s = strings.TrimSuffix(strings.TrimSpace(s), "/")
+		if _, rest, ok := strings.Cut(s, "://"); ok {
+			s = rest
+		}
+		host, path, _ := strings.Cut(s, "/")
+		return strings.ToLower(host) + "/" + path
+	}
+	return normalize(a) == normalize(b)
+}
+
+// contains reports whether s is present in list
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
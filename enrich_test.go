@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"go-browser-inventory/internal/browsers"
+	"go-browser-inventory/internal/webstore"
+)
+
+func TestExtensionStore(t *testing.T) {
+	tests := []struct {
+		name string
+		ext  browsers.Extension
+		want string
+	}{
+		{
+			name: "chrome web store in chrome",
+			ext:  browsers.Extension{Browser: "Chrome", UpdateURL: webstore.ChromeUpdateURL},
+			want: webstore.ChromeUpdateURL,
+		},
+		{
+			name: "chrome web store in edge",
+			ext:  browsers.Extension{Browser: "Edge", UpdateURL: webstore.ChromeUpdateURL},
+			want: webstore.ChromeUpdateURL,
+		},
+		{
+			name: "edge add-ons",
+			ext:  browsers.Extension{Browser: "Edge", UpdateURL: webstore.EdgeUpdateURL},
+			want: webstore.EdgeUpdateURL,
+		},
+		{
+			name: "old http store url",
+			ext:  browsers.Extension{Browser: "Chrome", UpdateURL: "http://Clients2.Google.com/service/update2/crx/"},
+			want: webstore.ChromeUpdateURL,
+		},
+		{
+			name: "no update url",
+			ext:  browsers.Extension{Browser: "Chrome"},
+		},
+		{
+			name: "self-hosted",
+			ext:  browsers.Extension{Browser: "Chrome", UpdateURL: "https://updates.example.com/crx"},
+		},
+		{
+			name: "unpacked",
+			ext:  browsers.Extension{Browser: "Chrome", UpdateURL: webstore.ChromeUpdateURL, InstallSource: browsers.InstallSourceUnpacked},
+		},
+		{
+			name: "external",
+			ext:  browsers.Extension{Browser: "Chrome", UpdateURL: webstore.ChromeUpdateURL, InstallSource: browsers.InstallSourceExternal},
+		},
+		{
+			name: "component",
+			ext:  browsers.Extension{Browser: "Chrome", UpdateURL: webstore.ChromeUpdateURL, Builtin: true},
+		},
+		{
+			name: "firefox",
+			ext:  browsers.Extension{Browser: "Firefox", UpdateURL: "https://versioncheck.addons.mozilla.org/update/VersionCheck.php"},
+		},
+	}
+	bi := browsers.NewBrowserInventory()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extensionStore(bi, tt.ext); got != tt.want {
+				t.Errorf("extensionStore() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
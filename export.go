@@ -0,0 +1,183 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-browser-inventory/internal/browsers"
+)
+
+// writeExport writes an -export archive: the JSON inventory as inventory.json
+// plus the manifest and locale files behind each extension, stored under
+// extensions/[user/]browser/profile/id/version/. The archive replaces dest
+// atomically.
+func writeExport(dest string, out output, debug bool) error {
+	f, err := createOutputFile(dest)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(f)
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		f.abort()
+		return fmt.Errorf("failed to marshal inventory: %w", err)
+	}
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "inventory.json", Method: zip.Deflate, Modified: time.Now()})
+	if err == nil {
+		_, err = w.Write(data)
+	}
+	if err != nil {
+		f.abort()
+		return fmt.Errorf("failed to write inventory.json: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, ext := range out.Extensions {
+		if ext.Path == "" {
+			continue
+		}
+		dir := exportDir(ext)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if err := addExtensionFiles(zw, dir, ext.Path); err != nil {
+			// Missing evidence for one extension shouldn't lose the rest
+			if debug {
+				fmt.Fprintf(os.Stderr, "Error exporting files for %s: %v\n", ext.ID, err)
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		f.abort()
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return f.commit()
+}
+
+// exportDir is the archive directory holding one extension's files
+func exportDir(ext browsers.Extension) string {
+	parts := []string{"extensions"}
+	if ext.User != "" {
+		parts = append(parts, exportSegment(ext.User))
+	}
+	parts = append(parts, exportSegment(ext.Browser), exportSegment(ext.Profile), exportSegment(ext.ID), exportSegment(ext.Version))
+	return path.Join(parts...)
+}
+
+// exportSegment makes a name safe to use as one archive path element
+func exportSegment(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, s)
+	if s == "" || s == "." || s == ".." {
+		return "_"
+	}
+	return s
+}
+
+// exportManifestFiles are copied from a Chromium version directory as-is,
+// including a compressed manifest left in place of the plain one
+var exportManifestFiles = []string{"manifest.json", "manifest.json.gz", "manifest.json.br"}
+
+// addExtensionFiles copies an extension's manifest and _locales message files
+// into the archive under dir. src is a Chromium version directory or a Firefox
+// .xpi package; anything else (such as an External Extensions declaration) has
+// nothing to copy.
+func addExtensionFiles(zw *zip.Writer, dir, src string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		if strings.EqualFold(filepath.Ext(src), ".xpi") {
+			return addXPIFiles(zw, dir, src)
+		}
+		return nil
+	}
+
+	for _, name := range exportManifestFiles {
+		if err := addFile(zw, path.Join(dir, name), filepath.Join(src, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	locales, err := os.ReadDir(filepath.Join(src, "_locales"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, locale := range locales {
+		name := path.Join(dir, "_locales", locale.Name(), "messages.json")
+		if err := addFile(zw, name, filepath.Join(src, "_locales", locale.Name(), "messages.json")); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// addFile copies the file at src into the archive as name
+func addFile(zw *zip.Writer, name, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	// Keep the original modification time; it is part of the evidence
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: info.ModTime()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, in)
+	return err
+}
+
+// addXPIFiles copies manifest.json and _locales/*/messages.json out of a
+// Firefox .xpi package
+func addXPIFiles(zw *zip.Writer, dir, src string) error {
+	xpi, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer xpi.Close()
+
+	for _, file := range xpi.File {
+		name := file.Name
+		if name != "manifest.json" {
+			parts := strings.Split(name, "/")
+			if len(parts) != 3 || parts[0] != "_locales" || parts[2] != "messages.json" {
+				continue
+			}
+		}
+		in, err := file.Open()
+		if err != nil {
+			return err
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: path.Join(dir, name), Method: zip.Deflate, Modified: file.Modified})
+		if err == nil {
+			_, err = io.Copy(w, in)
+		}
+		in.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,17 +1,24 @@
 package browsers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+
+	"go-browser-inventory/internal/webstore"
 )
 
-// NewBrowserInventory creates a new inventory instance
+// NewBrowserInventory creates a new inventory instance with the built-in
+// browsers and scanners registered
 func NewBrowserInventory() *BrowserInventory {
-	return &BrowserInventory{
+	bi := &BrowserInventory{
 		configs: []BrowserConfig{
 			{
 				Name: "Chrome",
@@ -24,8 +31,33 @@ func NewBrowserInventory() *BrowserInventory {
 				LinuxPath: []string{
 					".config", "google-chrome", "Default",
 				},
-				IsFirefox:    false,
-				ManifestFile: "manifest.json",
+				LinuxFallbackPaths: [][]string{
+					{".var", "app", "com.google.Chrome", "config", "google-chrome", "Default"}, // Flatpak
+				},
+				WindowsInstallPaths: []string{
+					`${ProgramFiles}\Google\Chrome\Application\chrome.exe`,
+					`${ProgramFiles(x86)}\Google\Chrome\Application\chrome.exe`,
+					`${LOCALAPPDATA}\Google\Chrome\Application\chrome.exe`,
+				},
+				MacOSInstallPaths: []string{
+					"/Applications/Google Chrome.app",
+					"~/Applications/Google Chrome.app",
+				},
+				LinuxInstallPaths: []string{
+					"/opt/google/chrome/chrome",
+					"/usr/bin/google-chrome",
+					"/usr/bin/google-chrome-stable",
+				},
+				MacOSExternalPaths: []string{
+					"/Library/Application Support/Google/Chrome/External Extensions",
+				},
+				LinuxExternalPaths: []string{
+					"/opt/google/chrome/extensions",
+					"/usr/share/google-chrome/extensions",
+				},
+				Scanner:        ScannerChromium,
+				ManifestFile:   "manifest.json",
+				StoreUpdateURL: webstore.ChromeUpdateURL,
 			},
 			{
 				Name: "Edge",
@@ -38,8 +70,104 @@ func NewBrowserInventory() *BrowserInventory {
 				LinuxPath: []string{
 					".config", "microsoft-edge", "Default",
 				},
-				IsFirefox:    false,
+				LinuxFallbackPaths: [][]string{
+					{".var", "app", "com.microsoft.Edge", "config", "microsoft-edge", "Default"}, // Flatpak
+				},
+				WindowsInstallPaths: []string{
+					`${ProgramFiles(x86)}\Microsoft\Edge\Application\msedge.exe`,
+					`${ProgramFiles}\Microsoft\Edge\Application\msedge.exe`,
+				},
+				MacOSInstallPaths: []string{
+					"/Applications/Microsoft Edge.app",
+					"~/Applications/Microsoft Edge.app",
+				},
+				LinuxInstallPaths: []string{
+					"/opt/microsoft/msedge/msedge",
+					"/usr/bin/microsoft-edge",
+					"/usr/bin/microsoft-edge-stable",
+				},
+				MacOSExternalPaths: []string{
+					"/Library/Application Support/Microsoft Edge/External Extensions",
+				},
+				LinuxExternalPaths: []string{
+					"/opt/microsoft/msedge/extensions",
+					"/usr/share/microsoft-edge/extensions",
+				},
+				Scanner:        ScannerChromium,
+				ManifestFile:   "manifest.json",
+				StoreUpdateURL: webstore.EdgeUpdateURL,
+				BuiltinIDs: []string{
+					"jmjflgjpcpepeafmmgdpfkogkghcpiha", // Edge relevant text changes
+					"ihmafllikibpmigkcoadcmckbfhibefp", // Edge Feedback
+				},
+			},
+			{
+				Name: "Chromium",
+				WindowsPath: []string{
+					"AppData", "Local", "Chromium", "User Data", "Default",
+				},
+				MacOSPath: []string{
+					"Library", "Application Support", "Chromium", "Default",
+				},
+				LinuxPath: []string{
+					".config", "chromium", "Default",
+				},
+				LinuxFallbackPaths: [][]string{
+					{"snap", "chromium", "common", "chromium", "Default"},                     // Snap
+					{".var", "app", "org.chromium.Chromium", "config", "chromium", "Default"}, // Flatpak
+				},
+				WindowsInstallPaths: []string{
+					`${LOCALAPPDATA}\Chromium\Application\chrome.exe`,
+				},
+				MacOSInstallPaths: []string{
+					"/Applications/Chromium.app",
+					"~/Applications/Chromium.app",
+				},
+				LinuxInstallPaths: []string{
+					"/usr/bin/chromium",
+					"/usr/bin/chromium-browser",
+					"/snap/bin/chromium",
+					"/usr/lib/chromium/chromium",
+				},
+				LinuxExternalPaths: []string{
+					"/usr/share/chromium/extensions",
+				},
+				Scanner:        ScannerChromium,
+				ManifestFile:   "manifest.json",
+				StoreUpdateURL: webstore.ChromeUpdateURL,
+			},
+			{
+				Name: "Yandex",
+				WindowsPath: []string{
+					"AppData", "Local", "Yandex", "YandexBrowser", "User Data", "Default",
+				},
+				MacOSPath: []string{
+					"Library", "Application Support", "Yandex", "YandexBrowser", "Default",
+				},
+				LinuxPath: []string{
+					".config", "yandex-browser", "Default",
+				},
+				LinuxFallbackPaths: [][]string{
+					{".config", "yandex-browser-beta", "Default"},
+				},
+				WindowsInstallPaths: []string{
+					`${LOCALAPPDATA}\Yandex\YandexBrowser\Application\browser.exe`,
+					`${ProgramFiles}\Yandex\YandexBrowser\Application\browser.exe`,
+				},
+				MacOSInstallPaths: []string{
+					"/Applications/Yandex.app",
+					"~/Applications/Yandex.app",
+				},
+				LinuxInstallPaths: []string{
+					"/opt/yandex/browser/yandex_browser",
+					"/usr/bin/yandex-browser",
+					"/usr/bin/yandex-browser-stable",
+				},
+				Scanner:      ScannerChromium,
 				ManifestFile: "manifest.json",
+				// Yandex installs from the Chrome Web Store as well as its own
+				// catalog, so Chrome store lookups cover most extensions
+				StoreUpdateURL: webstore.ChromeUpdateURL,
 			},
 			{
 				Name: "Firefox",
@@ -52,184 +180,459 @@ func NewBrowserInventory() *BrowserInventory {
 				LinuxPath: []string{
 					".mozilla", "firefox",
 				},
+				LinuxFallbackPaths: [][]string{
+					{".config", "mozilla", "firefox"},                             // XDG layout used by newer Firefox releases
+					{"snap", "firefox", "common", ".mozilla", "firefox"},          // Snap
+					{".var", "app", "org.mozilla.firefox", ".mozilla", "firefox"}, // Flatpak
+				},
+				WindowsInstallPaths: []string{
+					`${ProgramFiles}\Mozilla Firefox\firefox.exe`,
+					`${ProgramFiles(x86)}\Mozilla Firefox\firefox.exe`,
+				},
+				MacOSInstallPaths: []string{
+					"/Applications/Firefox.app",
+					"~/Applications/Firefox.app",
+				},
+				LinuxInstallPaths: []string{
+					"/usr/lib/firefox/firefox",
+					"/usr/lib64/firefox/firefox",
+					"/usr/bin/firefox",
+					"/snap/bin/firefox",
+				},
+				Scanner:      ScannerFirefox,
 				IsFirefox:    true,
 				ManifestFile: "manifest.json",
 			},
 		},
 	}
+	bi.registerBuiltinScanners()
+	return bi
+}
+
+// isBuiltinID reports whether id is one of the browser's component extensions
+func (config BrowserConfig) isBuiltinID(id string) bool {
+	for _, builtin := range config.BuiltinIDs {
+		if builtin == id {
+			return true
+		}
+	}
+	return false
 }
 
-// GetExtensions retrieves extensions based on browser selection
+// Config returns the configuration for the named browser (case-insensitive)
+func (bi *BrowserInventory) Config(name string) (BrowserConfig, bool) {
+	for _, config := range bi.configs {
+		if strings.EqualFold(config.Name, name) {
+			return config, true
+		}
+	}
+	return BrowserConfig{}, false
+}
+
+// BrowserNames returns the configured browser names in scan order
+func (bi *BrowserInventory) BrowserNames() []string {
+	names := make([]string, 0, len(bi.configs))
+	for _, config := range bi.configs {
+		names = append(names, config.Name)
+	}
+	return names
+}
+
+// allBrowsers is the -browser value selecting every configured browser
+const allBrowsers = "all"
+
+// ErrNoBrowsersConfigured is returned when a browser selection matches no
+// configured browser, so a misconfiguration isn't mistaken for a scan that
+// found nothing
+var ErrNoBrowsersConfigured = errors.New("no configured browser matches the selection")
+
+// ParseBrowsers turns a comma-separated browser selection into canonical
+// configured names, in the order given and without duplicates. An empty
+// selection, or one naming "all", means every configured browser; with no
+// browsers configured at all it returns ErrNoBrowsersConfigured.
+func (bi *BrowserInventory) ParseBrowsers(selection string) ([]string, error) {
+	if len(bi.configs) == 0 {
+		return nil, ErrNoBrowsersConfigured
+	}
+	if strings.TrimSpace(selection) == "" {
+		return bi.BrowserNames(), nil
+	}
+
+	var selected []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(selection, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if strings.EqualFold(part, allBrowsers) {
+			return bi.BrowserNames(), nil
+		}
+		config, ok := bi.Config(part)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown browser %q (valid: %s, %s)", ErrNoBrowsersConfigured, part, strings.Join(bi.BrowserNames(), ", "), allBrowsers)
+		}
+		if !seen[config.Name] {
+			seen[config.Name] = true
+			selected = append(selected, config.Name)
+		}
+	}
+	if len(selected) == 0 {
+		return bi.BrowserNames(), nil
+	}
+	return selected, nil
+}
+
+// Errors from basePathFor
+var (
+	errUnsupportedOS = errors.New("unsupported OS " + runtime.GOOS)
+	errNoHomeDir     = errors.New("user home directory is unavailable")
+)
+
+// GetExtensions retrieves extensions based on browser selection: one browser
+// by name, or with an empty name those in Options.Browsers (every configured
+// browser when it is empty). A missing home directory only fails the
+// browsers whose paths depend on it, and a data directory that can't be read
+// for lack of permission fails the scan when no selected browser could be
+// read; a selection matching no configured browser returns
+// ErrNoBrowsersConfigured.
 func (bi *BrowserInventory) GetExtensions(selectedBrowser string, debug bool) ([]Extension, error) {
 	var allExtensions []Extension
 
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %v", err)
+	var selected map[string]bool
+	if selectedBrowser == "" && len(bi.Options.Browsers) > 0 {
+		names, err := bi.ParseBrowsers(strings.Join(bi.Options.Browsers, ","))
+		if err != nil {
+			return nil, err
+		}
+		selected = make(map[string]bool, len(names))
+		for _, name := range names {
+			selected[name] = true
+		}
+	}
+
+	homeDir, err := bi.homeDir()
+	if err != nil && debug {
+		fmt.Printf("Warning: Failed to get user home directory: %v; skipping browsers that need it\n", err)
 	}
 
+	var matched, resolved, denied int
+	var pathErr, deniedErr error
 	for _, config := range bi.configs {
 		if selectedBrowser != "" && strings.ToLower(config.Name) != strings.ToLower(selectedBrowser) {
 			continue
 		}
+		if selected != nil && !selected[config.Name] {
+			continue
+		}
+		matched++
 
-		var basePath string
-		switch runtime.GOOS {
-		case "windows":
-			basePath = filepath.Join(homeDir, filepath.Join(config.WindowsPath...))
-		case "darwin": // macOS
-			basePath = filepath.Join(homeDir, filepath.Join(config.MacOSPath...))
-		case "linux":
-			basePath = filepath.Join(homeDir, filepath.Join(config.LinuxPath...))
-		default:
+		basePath, err := bi.basePath(config, homeDir)
+		if errors.Is(err, errUnsupportedOS) {
 			if debug {
 				fmt.Printf("Warning: Unsupported OS %s for %s\n", runtime.GOOS, config.Name)
 			}
 			continue
 		}
+		if err != nil {
+			if debug {
+				fmt.Printf("Warning: Skipping %s: %v\n", config.Name, err)
+			}
+			pathErr = fmt.Errorf("failed to resolve %s path: %w", config.Name, err)
+			continue
+		}
+		resolved++
+		if debug {
+			fmt.Printf("Debug: Using %s data at %s\n", config.Name, basePath)
+		}
 
-		var exts []Extension
-		if config.IsFirefox {
-			exts, err = bi.getFirefoxExtensions(basePath, config, debug)
-		} else {
-			exts, err = bi.getChromiumExtensions(basePath, config, debug)
+		scanner, err := bi.scannerFor(config)
+		if err != nil {
+			if debug {
+				fmt.Printf("Warning: Skipping %s: %v\n", config.Name, err)
+			}
+			continue
 		}
+		exts, err := scanner.Scan(context.Background(), basePath, config, debug)
 		if err != nil {
 			if debug {
 				fmt.Printf("Warning: Failed to get %s extensions: %v\n", config.Name, err)
 			}
+			if errors.Is(err, fs.ErrPermission) {
+				denied++
+				deniedErr = fmt.Errorf("%s: %w", config.Name, err)
+			}
 			continue
 		}
 		allExtensions = append(allExtensions, exts...)
 	}
 
+	if matched == 0 {
+		if selectedBrowser == "" {
+			return nil, ErrNoBrowsersConfigured
+		}
+		return nil, fmt.Errorf("%w: %s", ErrNoBrowsersConfigured, selectedBrowser)
+	}
+	if resolved == 0 && pathErr != nil {
+		return nil, pathErr
+	}
+	// A browser that is present but can't be read at all has failed, unlike
+	// one that isn't installed
+	if resolved > 0 && denied == resolved {
+		return nil, deniedErr
+	}
 	return allExtensions, nil
 }
 
-// resolveMessage handles __MSG_ placeholders for extension names
-func resolveMessage(msg, basePath, defaultLocale string, debug bool) string {
+// FindByID scans the browsers in Options.Browsers (every configured browser
+// when it is empty) and returns the installs of the extension with the given
+// ID across browsers and profiles
+func (bi *BrowserInventory) FindByID(id string, debug bool) ([]Extension, error) {
+	extensions, err := bi.GetExtensions("", debug)
+	if err != nil {
+		return nil, err
+	}
+	return FilterByID(extensions, id), nil
+}
+
+// homeDir returns Options.HomeDir when set, otherwise the current user's home
+func (bi *BrowserInventory) homeDir() (string, error) {
+	if bi.Options.HomeDir != "" {
+		return bi.Options.HomeDir, nil
+	}
+	return os.UserHomeDir()
+}
+
+// basePath returns the browser's base path, honoring Options.PortableRoot for
+// Chromium browsers. $XDG_CONFIG_HOME belongs to the current user, so it is
+// ignored when scanning another user's Options.HomeDir.
+func (bi *BrowserInventory) basePath(config BrowserConfig, homeDir string) (string, error) {
+	if bi.Options.PortableRoot != "" && !config.IsFirefox {
+		return filepath.Join(bi.Options.PortableRoot, "Default"), nil
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if bi.Options.HomeDir != "" {
+		configHome = ""
+	}
+	return basePathFor(config, homeDir, configHome)
+}
+
+// basePathFor computes the browser's base path for the current OS. homeDir
+// may be empty, in which case only paths that don't depend on it resolve.
+// configHome is the $XDG_CONFIG_HOME to apply on Linux, if any.
+func basePathFor(config BrowserConfig, homeDir, configHome string) (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		return homePath(config.WindowsPath, homeDir)
+	case "darwin": // macOS
+		return homePath(config.MacOSPath, homeDir)
+	case "linux":
+		primary, primaryErr := linuxPath(config.LinuxPath, homeDir, configHome)
+		if primaryErr == nil {
+			if _, err := os.Stat(primary); err == nil {
+				return primary, nil
+			}
+		}
+		for _, candidate := range config.LinuxFallbackPaths {
+			path, err := linuxPath(candidate, homeDir, configHome)
+			if err != nil {
+				continue
+			}
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+		return primary, primaryErr
+	default:
+		return "", errUnsupportedOS
+	}
+}
+
+// homePath joins parts under the home directory
+func homePath(parts []string, homeDir string) (string, error) {
+	if homeDir == "" {
+		return "", errNoHomeDir
+	}
+	return filepath.Join(homeDir, filepath.Join(parts...)), nil
+}
+
+// linuxPath joins a Linux path under the home directory, rebasing paths that
+// start with .config onto configHome ($XDG_CONFIG_HOME) when it is set
+func linuxPath(parts []string, homeDir, configHome string) (string, error) {
+	if len(parts) > 0 && parts[0] == ".config" {
+		if configHome != "" && filepath.IsAbs(configHome) {
+			return filepath.Join(configHome, filepath.Join(parts[1:]...)), nil
+		}
+	}
+	return homePath(parts, homeDir)
+}
+
+// ResolvePaths computes the paths a scan would read for each selected browser
+// without scanning them
+func (bi *BrowserInventory) ResolvePaths(selectedBrowser string) ([]BrowserPaths, error) {
+	homeDir, _ := bi.homeDir()
+
+	var all []BrowserPaths
+	for _, config := range bi.configs {
+		if selectedBrowser != "" && !strings.EqualFold(config.Name, selectedBrowser) {
+			continue
+		}
+
+		bp := BrowserPaths{Browser: config.Name}
+		basePath, err := bi.basePath(config, homeDir)
+		if errors.Is(err, errUnsupportedOS) {
+			bp.Unsupported = true
+			all = append(all, bp)
+			continue
+		}
+		if err != nil {
+			bp.Error = err.Error()
+			all = append(all, bp)
+			continue
+		}
+
+		if config.IsFirefox {
+			bp.Paths = []PathCheck{
+				newPathCheck("Profiles directory", basePath),
+				newPathCheck("profiles.ini", filepath.Join(basePath, "profiles.ini")),
+			}
+		} else {
+			bp.Paths = []PathCheck{
+				newPathCheck("Base path", basePath),
+				newPathCheck("Profile base", filepath.Dir(basePath)),
+				newPathCheck("Extensions", filepath.Join(basePath, "Extensions")),
+			}
+		}
+		all = append(all, bp)
+	}
+	return all, nil
+}
+
+// newPathCheck records whether path exists
+func newPathCheck(label, path string) PathCheck {
+	_, err := os.Stat(path)
+	return PathCheck{Label: label, Path: path, Exists: err == nil}
+}
+
+// resolveMessage handles __MSG_ placeholders for extension names. When no
+// locale defines the key it returns the bare key and false.
+func (bi *BrowserInventory) resolveMessage(msg, basePath, defaultLocale string, debug bool) (string, bool) {
 	msgKey := strings.TrimPrefix(msg, "__MSG_")
 	msgKey = strings.TrimSuffix(msgKey, "__")
-	lookupKey := strings.ToLower(msgKey) // Lowercase for consistency
-	lookupKeyOriginal := msgKey          // Original case for exact match
+	if msgKey == "" {
+		// Degenerate placeholder such as __MSG___; nothing to look up
+		if debug {
+			fmt.Printf("Note: Empty message key in %q\n", msg)
+		}
+		return msg, false
+	}
 	localesPath := filepath.Join(basePath, "_locales")
 	if debug {
 		fmt.Printf("Debug: Resolving %s in %s\n", msgKey, basePath)
 	}
 
-	if _, err := os.Stat(localesPath); os.IsNotExist(err) {
-		if debug {
-			fmt.Printf("Note: No _locales directory at %s\n", localesPath)
-		}
-		return msgKey
+	// Precedence: default_locale, en, en_US, then the remaining locales in
+	// name order, so the same extension always resolves the same way
+	preferred := []string{"en", "en_US"}
+	switch defaultLocale {
+	case "", "en":
+	case "en_US":
+		preferred = []string{"en_US", "en"}
+	default:
+		preferred = []string{defaultLocale, "en", "en_US"}
 	}
-
-	localeDirs, err := os.ReadDir(localesPath)
-	if err != nil {
-		if debug {
-			fmt.Printf("Warning: Failed to read _locales: %v\n", err)
+	for _, locale := range preferred {
+		if val, ok := bi.lookupMessage(filepath.Join(localesPath, locale, "messages.json"), msgKey, debug); ok {
+			return val, true
 		}
-		return msgKey
 	}
 
-	// Try English locales first
-	for _, enLocale := range []string{"en", "en_US"} {
-		messagesPath := filepath.Join(localesPath, enLocale, "messages.json")
-		if data, err := os.ReadFile(messagesPath); err == nil {
-			var messages map[string]struct {
-				Message string `json:"message"`
-			}
-			if err := json.Unmarshal(data, &messages); err == nil {
-				if debug {
-					fmt.Printf("Debug: Checking %s\n", messagesPath)
-				}
-				// Try original case first
-				if val, ok := messages[lookupKeyOriginal]; ok {
-					if debug {
-						fmt.Printf("Debug: Resolved %s to %s (original case)\n", msgKey, val.Message)
-					}
-					return val.Message
-				}
-				// Then try lowercase
-				if val, ok := messages[lookupKey]; ok {
-					if debug {
-						fmt.Printf("Debug: Resolved %s to %s (lowercase)\n", msgKey, val.Message)
-					}
-					return val.Message
-				}
-			} else if debug {
-				fmt.Printf("Warning: Failed to parse %s: %v\n", messagesPath, err)
-			}
-		} else if debug {
-			fmt.Printf("Debug: %s not found\n", messagesPath)
+	if bi.Options.NoFallbackLocale {
+		if debug {
+			fmt.Printf("Note: No match for %s in preferred locales, leaving it unresolved\n", msgKey)
 		}
+		return msgKey, false
 	}
 
-	// Try default_locale if not English
-	if defaultLocale != "" && defaultLocale != "en" && defaultLocale != "en_US" {
-		messagesPath := filepath.Join(localesPath, defaultLocale, "messages.json")
-		if data, err := os.ReadFile(messagesPath); err == nil {
-			var messages map[string]struct {
-				Message string `json:"message"`
+	// Fallback to other locales. The directory is only listed once the
+	// preferred locales miss, which is the uncommon case.
+	localeDirs, err := os.ReadDir(localesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if debug {
+				fmt.Printf("Note: No _locales directory at %s\n", localesPath)
 			}
-			if err := json.Unmarshal(data, &messages); err == nil {
-				if debug {
-					fmt.Printf("Debug: Checking %s\n", messagesPath)
-				}
-				// Try original case first
-				if val, ok := messages[lookupKeyOriginal]; ok {
-					if debug {
-						fmt.Printf("Debug: Resolved %s to %s (original case)\n", msgKey, val.Message)
-					}
-					return val.Message
-				}
-				// Then try lowercase
-				if val, ok := messages[lookupKey]; ok {
-					if debug {
-						fmt.Printf("Debug: Resolved %s to %s (lowercase)\n", msgKey, val.Message)
-					}
-					return val.Message
-				}
-			} else if debug {
-				fmt.Printf("Warning: Failed to parse %s: %v\n", messagesPath, err)
+		} else {
+			if debug {
+				fmt.Printf("Warning: Failed to read _locales: %v\n", err)
 			}
-		} else if debug {
-			fmt.Printf("Debug: %s not found\n", messagesPath)
+			bi.recordFileError(localesPath, err)
 		}
+		return msgKey, false
 	}
-
-	// Fallback to other locales
+	var remaining []string
 	for _, dir := range localeDirs {
 		if !dir.IsDir() || dir.Name() == defaultLocale || dir.Name() == "en" || dir.Name() == "en_US" {
 			continue
 		}
-		messagesPath := filepath.Join(localesPath, dir.Name(), "messages.json")
-		if data, err := os.ReadFile(messagesPath); err == nil {
-			var messages map[string]struct {
-				Message string `json:"message"`
-			}
-			if err := json.Unmarshal(data, &messages); err == nil {
-				if debug {
-					fmt.Printf("Debug: Checking %s\n", messagesPath)
-				}
-				if val, ok := messages[lookupKeyOriginal]; ok {
-					if debug {
-						fmt.Printf("Debug: Resolved %s to %s (original case)\n", msgKey, val.Message)
-					}
-					return val.Message
-				}
-				if val, ok := messages[lookupKey]; ok {
-					if debug {
-						fmt.Printf("Debug: Resolved %s to %s (lowercase)\n", msgKey, val.Message)
-					}
-					return val.Message
-				}
-			}
+		remaining = append(remaining, dir.Name())
+	}
+	// os.ReadDir sorts by name today; sort anyway so the precedence doesn't
+	// depend on it
+	sort.Strings(remaining)
+	for _, locale := range remaining {
+		if val, ok := bi.lookupMessage(filepath.Join(localesPath, locale, "messages.json"), msgKey, debug); ok {
+			return val, true
 		}
 	}
 
 	if debug {
 		fmt.Printf("Note: No match for %s in %s\n", msgKey, localesPath)
 	}
-	return msgKey
+	return msgKey, false
+}
+
+// lookupMessage looks msgKey up in one messages.json, trying the original case
+// first and then lowercase
+func (bi *BrowserInventory) lookupMessage(messagesPath, msgKey string, debug bool) (string, bool) {
+	data, err := os.ReadFile(messagesPath)
+	if err != nil {
+		if debug {
+			fmt.Printf("Debug: %s not found\n", messagesPath)
+		}
+		if !os.IsNotExist(err) {
+			bi.recordFileError(messagesPath, err)
+		}
+		return "", false
+	}
+
+	var messages map[string]struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(decodeText(data), &messages); err != nil {
+		if debug {
+			fmt.Printf("Warning: Failed to parse %s: %v\n", messagesPath, err)
+		}
+		bi.recordFileError(messagesPath, err)
+		return "", false
+	}
+	if debug {
+		fmt.Printf("Debug: Checking %s\n", messagesPath)
+	}
+
+	// Try original case first
+	if val, ok := messages[msgKey]; ok {
+		if debug {
+			fmt.Printf("Debug: Resolved %s to %s (original case)\n", msgKey, val.Message)
+		}
+		return val.Message, true
+	}
+	// Then try lowercase
+	if val, ok := messages[strings.ToLower(msgKey)]; ok {
+		if debug {
+			fmt.Printf("Debug: Resolved %s to %s (lowercase)\n", msgKey, val.Message)
+		}
+		return val.Message, true
+	}
+	return "", false
 }
@@ -0,0 +1,550 @@
+package browsers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fixtureHome is the miniature home directory described in testdata/README.md
+const fixtureHome = "testdata/home"
+
+// newFixtureInventory returns an inventory that scans home instead of the
+// current user's home directory
+func newFixtureInventory(t *testing.T, home string) *BrowserInventory {
+	t.Helper()
+	abs, err := filepath.Abs(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bi := NewBrowserInventory()
+	bi.Options.HomeDir = abs
+	bi.Options.Lenient = true
+	return bi
+}
+
+// scanFixture runs the browser's registered scanner (getChromiumExtensions or
+// getFirefoxExtensions) against the inventory's home
+func scanFixture(t *testing.T, bi *BrowserInventory, browser string) []Extension {
+	t.Helper()
+	config, ok := bi.Config(browser)
+	if !ok {
+		t.Fatalf("no %s config", browser)
+	}
+	basePath, err := bi.basePath(config, bi.Options.HomeDir)
+	if err != nil {
+		t.Fatalf("basePath(%s): %v", browser, err)
+	}
+	scanner, err := bi.scannerFor(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exts, err := scanner.Scan(context.Background(), basePath, config, false)
+	if err != nil {
+		t.Fatalf("scanning %s: %v", browser, err)
+	}
+	return exts
+}
+
+// fixtureRow is the subset of Extension checked against each fixture: the
+// fields testdata/expected.txt lists, plus those the scanners derive from
+// Preferences, extensions.json, and manifest keys
+type fixtureRow struct {
+	Profile         string
+	ID              string
+	Name            string
+	ShortName       string
+	Version         string
+	Enabled         bool
+	DisabledReason  string
+	Builtin         bool
+	Permissions     []string
+	HostPermissions []string
+	Author          string
+	InstallSource   string
+	// Not in expected.txt; ComputedID needs Options.VerifyIDs
+	IncognitoAllowed   bool
+	InstalledBy        string
+	DuplicateInProfile bool
+	ComputedID         string
+}
+
+func rowOf(e Extension) fixtureRow {
+	row := fixtureRow{
+		Profile:        e.Profile,
+		ID:             e.ID,
+		Name:           e.Name,
+		ShortName:      e.ShortName,
+		Version:        e.Version,
+		Enabled:        e.Enabled,
+		DisabledReason: e.DisabledReason,
+		Builtin:        e.Builtin,
+		Author:         e.Author,
+		InstallSource:  e.InstallSource,
+
+		IncognitoAllowed:   e.IncognitoAllowed,
+		InstalledBy:        e.InstalledBy,
+		DuplicateInProfile: e.DuplicateInProfile,
+		ComputedID:         e.ComputedID,
+	}
+	if len(e.Permissions) > 0 {
+		row.Permissions = e.Permissions
+	}
+	if len(e.HostPermissions) > 0 {
+		row.HostPermissions = e.HostPermissions
+	}
+	return row
+}
+
+func TestScanFixtures(t *testing.T) {
+	tests := []struct {
+		browser string
+		want    []fixtureRow
+		// wantConflicts are the IDs FindKeyConflicts reports
+		wantConflicts []string
+	}{
+		{
+			browser: "Chrome",
+			// Profile 10's copy of the keyed extension carries another key
+			wantConflicts: []string{"pjhljbkjcfhaehpdajpeadceelfacnap"},
+			want: []fixtureRow{
+				{Profile: "Person 1", ID: "aaaabbbbccccddddeeeeffffgggghhhh", Name: "Locale Resolved Extension", ShortName: "Locale Ext", Version: "3.2.1", Enabled: true, Permissions: []string{"storage", "tabs"}, HostPermissions: []string{"https://*.example.com/*"}, IncognitoAllowed: true},
+				{Profile: "Person 1", ID: "dddddddddddddddddddddddddddddddd", Name: "Action Title Fallback", Version: "1.0", Enabled: true},
+				{Profile: "Person 1", ID: "llllmmmmnnnnooooppppoooonnnnmmmm", Name: "Nom de la locale par défaut", Version: "1.0", Enabled: true, Author: "Équipe de la locale", InstalledBy: "policy"},
+				{Profile: "Person 1", ID: "mmmmnnnnooooppppmmmmnnnnoooopppp", Name: "Deutscher Name", Version: "1.0", Enabled: true, InstalledBy: "default"},
+				{Profile: "Person 1", ID: "ppppoooonnnnmmmmllllkkkkjjjjiiii", Name: "Disabled By User", Version: "0.9", DisabledReason: "user", Permissions: []string{"tabs"}, HostPermissions: []string{"<all_urls>", "http://*/*"}},
+				{Profile: "Work", ID: "abcdefghijklmnopabcdefghijklmnop", Name: "Work Profile Extension", Version: "2.1", Enabled: true, Permissions: []string{"cookies"}, Author: "extensions@work.example"},
+				{Profile: "Work", ID: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Name: "Stray Old Copy", Version: "0.9", Enabled: true, DuplicateInProfile: true},
+				{Profile: "Work", ID: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Name: "Manifest With BOM", Version: "1.0", Enabled: true, DuplicateInProfile: true},
+				{Profile: "Work", ID: "cccccccccccccccccccccccccccccccc", Name: "Trailing Comma, Lenient Only", Version: "1.0", Enabled: true, Permissions: []string{"storage"}},
+				{Profile: "Work", ID: "gggggggggggggggggggggggggggggggg", Name: "Compressed Manifest Only", Version: "1.5", Enabled: true},
+				{Profile: "Work", ID: "kkkkllllmmmmnnnnkkkkllllmmmmnnnn", Name: "UTF-16 Ünïcode Name", Version: "1.0", Enabled: true},
+				{Profile: "Profile 2", ID: "mmmmmmmmmmmmmmmmmmmmmmmmmmmmmmaa", Name: "Profile 2 Extension", Version: "1.0", Enabled: true},
+				{Profile: "Profile 2", ID: "pjhljbkjcfhaehpdajpeadceelfacnap", Name: "Keyed Extension", Version: "1.0", Enabled: true, ComputedID: "pjhljbkjcfhaehpdajpeadceelfacnap"},
+				{Profile: "Profile 10", ID: "mmmmmmmmmmmmmmmmmmmmmmmmmmmmmmbb", Name: "Profile 10 Extension", Version: "1.0", Enabled: true},
+				{Profile: "Profile 10", ID: "pjhljbkjcfhaehpdajpeadceelfacnap", Name: "Keyed Extension", Version: "1.0", Enabled: true, ComputedID: "nhcmhjmkieialikajnkedkhcanggmdei"},
+			},
+		},
+		{
+			browser: "Edge",
+			want: []fixtureRow{
+				{Profile: "Profile 1", ID: "hhhhggggffffeeeeddddccccbbbbaaaa", Name: "Edge User Extension", Version: "5.0", Enabled: true, Author: "Edge Extension Team", InstallSource: "external"},
+				{Profile: "Profile 1", ID: "jjjjkkkkllllmmmmjjjjkkkkllllmmmm", Name: "UTF-16BE Manifest", Version: "2.0", Enabled: true, Permissions: []string{"storage"}},
+				{Profile: "Profile 1", ID: "jmjflgjpcpepeafmmgdpfkogkghcpiha", Name: "Microsoft Edge relevant text changes", Version: "1.0.0.1", Enabled: true, Builtin: true},
+				{ID: "iiiijjjjkkkkllllmmmmnnnnoooopppp", Version: "2.0", InstallSource: "external"},
+			},
+		},
+		{
+			browser: "Chromium",
+			want: []fixtureRow{
+				{Profile: "Snapshot Person", ID: "nnnnaaaappppkkkknnnnaaaappppkkkk", Name: "Snapshot Extension", Version: "2.0", Enabled: true, Permissions: []string{"storage"}},
+			},
+		},
+		{
+			browser: "Firefox",
+			want: []fixtureRow{
+				{Profile: "abcd1234.default-release", ID: "uBlock0@raymondhill.net", Name: "uBlock Origin", Version: "1.44.4", Enabled: true, Permissions: []string{"storage", "tabs"}, HostPermissions: []string{"<all_urls>"}, Author: "Raymond Hill", IncognitoAllowed: true},
+				{Profile: "abcd1234.default-release", ID: "disabled@example.com", Name: "Disabled Firefox Add-on", Version: "0.1", DisabledReason: "user", InstalledBy: "external"},
+				{Profile: "abcd1234.default-release", ID: "sunset-theme@example.com", Name: "Sunset Theme", Version: "1.0", Enabled: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.browser, func(t *testing.T) {
+			bi := newFixtureInventory(t, fixtureHome)
+			bi.Options.VerifyIDs = true
+			exts := scanFixture(t, bi, tt.browser)
+			var got, incognito []fixtureRow
+			for _, e := range exts {
+				if e.Browser != tt.browser {
+					t.Errorf("%s %s: Browser = %q, want %q", e.Profile, e.ID, e.Browser, tt.browser)
+				}
+				got = append(got, rowOf(e))
+				if e.IncognitoAllowed {
+					incognito = append(incognito, rowOf(e))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows mismatch\ngot:\n%s\nwant:\n%s", formatRows(got), formatRows(tt.want))
+			}
+
+			var filtered []fixtureRow
+			for _, e := range FilterIncognito(exts) {
+				filtered = append(filtered, rowOf(e))
+			}
+			if !reflect.DeepEqual(filtered, incognito) {
+				t.Errorf("FilterIncognito rows mismatch\ngot:\n%s\nwant:\n%s", formatRows(filtered), formatRows(incognito))
+			}
+
+			var conflicts []string
+			for _, c := range FindKeyConflicts(exts) {
+				conflicts = append(conflicts, c.ID)
+			}
+			if !reflect.DeepEqual(conflicts, tt.wantConflicts) {
+				t.Errorf("key conflicts = %q, want %q", conflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func formatRows(rows []fixtureRow) string {
+	var b strings.Builder
+	for _, r := range rows {
+		fmt.Fprintf(&b, "\t%+v\n", r)
+	}
+	return b.String()
+}
+
+// TestStrictTrailingComma checks that without Lenient the fixture's
+// trailing-comma manifest is skipped and recorded as a file error
+func TestStrictTrailingComma(t *testing.T) {
+	const id = "cccccccccccccccccccccccccccccccc"
+	bi := newFixtureInventory(t, fixtureHome)
+	bi.Options.Lenient = false
+	for _, ext := range scanFixture(t, bi, "chrome") {
+		if ext.ID == id {
+			t.Errorf("%s listed without Lenient", id)
+		}
+	}
+	var recorded bool
+	for _, fe := range bi.FileErrors() {
+		if strings.Contains(fe.Path, id) && filepath.Base(fe.Path) == "manifest.json" {
+			recorded = true
+		}
+	}
+	if !recorded {
+		t.Errorf("no file error recorded for %s's manifest: %+v", id, bi.FileErrors())
+	}
+
+	bi = newFixtureInventory(t, fixtureHome)
+	var found bool
+	for _, ext := range scanFixture(t, bi, "chrome") {
+		found = found || ext.ID == id
+	}
+	if !found {
+		t.Errorf("%s not listed with Lenient", id)
+	}
+}
+
+func TestResolveMessage(t *testing.T) {
+	extensions := filepath.Join(fixtureHome, ".config", "google-chrome", "Default", "Extensions")
+	tests := []struct {
+		name          string
+		msg           string
+		dir           string
+		defaultLocale string
+		want          string
+		wantOK        bool
+	}{
+		{
+			name:          "default locale",
+			msg:           "__MSG_appShortName__",
+			dir:           "aaaabbbbccccddddeeeeffffgggghhhh/3.2.1_0",
+			defaultLocale: "en",
+			want:          "Locale Ext",
+			wantOK:        true,
+		},
+		{
+			name:          "lowercase key fallback",
+			msg:           "__MSG_appName__",
+			dir:           "aaaabbbbccccddddeeeeffffgggghhhh/3.2.1_0",
+			defaultLocale: "en",
+			want:          "Locale Resolved Extension",
+			wantOK:        true,
+		},
+		{
+			name:          "unknown key returns bare key",
+			msg:           "__MSG_missing__",
+			dir:           "aaaabbbbccccddddeeeeffffgggghhhh/3.2.1_0",
+			defaultLocale: "en",
+			want:          "missing",
+		},
+		{
+			name:          "empty key",
+			msg:           "__MSG___",
+			dir:           "aaaabbbbccccddddeeeeffffgggghhhh/3.2.1_0",
+			defaultLocale: "en",
+			want:          "__MSG___",
+		},
+		{
+			name:          "underscore key",
+			msg:           "__MSG__",
+			dir:           "aaaabbbbccccddddeeeeffffgggghhhh/3.2.1_0",
+			defaultLocale: "en",
+			want:          "_",
+		},
+		{
+			name:          "single-character key without closing underscores",
+			msg:           "__MSG_x",
+			dir:           "aaaabbbbccccddddeeeeffffgggghhhh/3.2.1_0",
+			defaultLocale: "en",
+			want:          "Single Letter Key",
+			wantOK:        true,
+		},
+		{
+			name:          "single-character key by lowercase",
+			msg:           "__MSG_X__",
+			dir:           "aaaabbbbccccddddeeeeffffgggghhhh/3.2.1_0",
+			defaultLocale: "en",
+			want:          "Single Letter Key",
+			wantOK:        true,
+		},
+		{
+			name:          "unknown single-character key",
+			msg:           "__MSG_q",
+			dir:           "aaaabbbbccccddddeeeeffffgggghhhh/3.2.1_0",
+			defaultLocale: "en",
+			want:          "q",
+		},
+		{
+			name: "no _locales directory",
+			msg:  "__MSG_appName__",
+			dir:  "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb/1.0_0",
+			want: "appName",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bi := NewBrowserInventory()
+			got, ok := bi.resolveMessage(tt.msg, filepath.Join(extensions, filepath.FromSlash(tt.dir)), tt.defaultLocale, false)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("resolveMessage(%q) = %q, %v; want %q, %v", tt.msg, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLocalePrecedence(t *testing.T) {
+	tests := []struct {
+		name          string
+		locales       []string
+		defaultLocale string
+		noFallback    bool
+		want          string
+		wantOK        bool
+	}{
+		{name: "default_locale first", locales: []string{"de", "en", "en_US", "fr"}, defaultLocale: "fr", want: "fr", wantOK: true},
+		{name: "en before en_US", locales: []string{"de", "en", "en_US"}, defaultLocale: "de_AT", want: "en", wantOK: true},
+		{name: "en_US without en", locales: []string{"de", "en_US"}, want: "en_US", wantOK: true},
+		{name: "en default_locale", locales: []string{"en", "en_US"}, defaultLocale: "en", want: "en", wantOK: true},
+		{name: "en_US default_locale before en", locales: []string{"en", "en_US"}, defaultLocale: "en_US", want: "en_US", wantOK: true},
+		{name: "remaining locales by name", locales: []string{"pt_BR", "ja", "de"}, want: "de", wantOK: true},
+		{name: "base language before region", locales: []string{"es_419", "es"}, defaultLocale: "pt", want: "es", wantOK: true},
+		{name: "no fallback", locales: []string{"de", "fr"}, noFallback: true, want: "appName"},
+		{name: "no fallback keeps default_locale", locales: []string{"de", "fr"}, defaultLocale: "fr", noFallback: true, want: "fr", wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, locale := range tt.locales {
+				writeFile(t, filepath.Join(dir, "_locales", locale, "messages.json"),
+					`{"appName": {"message": "`+locale+`"}}`)
+			}
+			bi := NewBrowserInventory()
+			bi.Options.NoFallbackLocale = tt.noFallback
+			got, ok := bi.resolveMessage("__MSG_appName__", dir, tt.defaultLocale, false)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("resolveMessage with %v, default_locale %q = %q, %v; want %q, %v",
+					tt.locales, tt.defaultLocale, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// writeFile creates path and its parent directories with the given contents
+func writeFile(t testing.TB, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeChromiumExtension writes a minimal manifest for id under a profile's
+// Extensions directory
+func writeChromiumExtension(t *testing.T, profileDir, id, name string) {
+	t.Helper()
+	writeFile(t, filepath.Join(profileDir, "Extensions", id, "1.0_0", "manifest.json"),
+		`{"manifest_version": 3, "name": "`+name+`", "version": "1.0"}`)
+}
+
+// denyAccess removes all permissions from path until the test ends
+func denyAccess(t *testing.T, path string) {
+	t.Helper()
+	if err := os.Chmod(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(path, 0o755) })
+}
+
+func TestPermissionDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission checks don't apply to root")
+	}
+	const readableID = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+	t.Run("profile is skipped", func(t *testing.T) {
+		home := t.TempDir()
+		userData := filepath.Join(home, ".config", "google-chrome")
+		writeChromiumExtension(t, filepath.Join(userData, "Default"), readableID, "Readable")
+		writeChromiumExtension(t, filepath.Join(userData, "Profile 1"), "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", "Unreadable")
+		denyAccess(t, filepath.Join(userData, "Profile 1"))
+
+		bi := newFixtureInventory(t, home)
+		exts, err := bi.GetExtensions("Chrome", false)
+		if err != nil {
+			t.Fatalf("GetExtensions: %v", err)
+		}
+		if len(exts) != 1 || exts[0].ID != readableID {
+			t.Errorf("got %d extensions, want only %s", len(exts), readableID)
+		}
+		want := []SkippedPath{{Path: filepath.Join(userData, "Profile 1"), Reason: skipReasonPermissionDenied}}
+		if got := bi.SkippedPaths(); !reflect.DeepEqual(got, want) {
+			t.Errorf("SkippedPaths() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("unreadable data directory fails the browser", func(t *testing.T) {
+		home := t.TempDir()
+		userData := filepath.Join(home, ".config", "google-chrome")
+		writeChromiumExtension(t, filepath.Join(userData, "Default"), readableID, "Readable")
+		denyAccess(t, userData)
+
+		bi := newFixtureInventory(t, home)
+		if _, err := bi.GetExtensions("Chrome", false); !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("GetExtensions error = %v, want a permission error", err)
+		}
+		if got := bi.SkippedPaths(); len(got) != 1 || got[0].Path != userData {
+			t.Errorf("SkippedPaths() = %v, want %s", got, userData)
+		}
+	})
+}
+
+func TestBasePathXDGConfigHome(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG_CONFIG_HOME only applies on Linux")
+	}
+	home := t.TempDir()
+	xdg := t.TempDir()
+	tests := []struct {
+		name      string
+		xdg       string
+		otherUser bool
+		browser   string
+		want      string
+	}{
+		{name: "set", xdg: xdg, browser: "Chrome", want: filepath.Join(xdg, "google-chrome", "Default")},
+		{name: "unset", xdg: "", browser: "Chrome", want: filepath.Join(home, ".config", "google-chrome", "Default")},
+		{name: "relative is ignored", xdg: "relative/config", browser: "Chrome", want: filepath.Join(home, ".config", "google-chrome", "Default")},
+		{name: "other user's home ignores it", xdg: xdg, otherUser: true, browser: "Chrome", want: filepath.Join(home, ".config", "google-chrome", "Default")},
+		{name: "Firefox is outside .config", xdg: xdg, browser: "Firefox", want: filepath.Join(home, ".mozilla", "firefox")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", tt.xdg)
+			bi := NewBrowserInventory()
+			if tt.otherUser {
+				bi.Options.HomeDir = home
+			}
+			config, _ := bi.Config(tt.browser)
+			got, err := bi.basePath(config, home)
+			if err != nil {
+				t.Fatalf("basePath: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("basePath = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionsBrowsers(t *testing.T) {
+	tests := []struct {
+		name     string
+		browsers []string
+		want     []string
+	}{
+		{name: "subset", browsers: []string{"chrome", " EDGE "}, want: []string{"Chrome", "Edge"}},
+		{name: "single", browsers: []string{"Firefox"}, want: []string{"Firefox"}},
+		{name: "all", browsers: []string{"edge", "all"}, want: []string{"Chrome", "Edge", "Chromium", "Firefox"}},
+		{name: "unset", want: []string{"Chrome", "Edge", "Chromium", "Firefox"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bi := newFixtureInventory(t, fixtureHome)
+			bi.Options.Browsers = tt.browsers
+			exts, err := bi.GetExtensions("", false)
+			if err != nil {
+				t.Fatalf("GetExtensions: %v", err)
+			}
+			found := make(map[string]bool)
+			for _, ext := range exts {
+				found[ext.Browser] = true
+			}
+			var got []string
+			for _, name := range bi.BrowserNames() {
+				if found[name] {
+					got = append(got, name)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("browsers scanned = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionsBrowsersUnknown(t *testing.T) {
+	bi := newFixtureInventory(t, fixtureHome)
+	bi.Options.Browsers = []string{"chrome", "netscape"}
+	_, err := bi.GetExtensions("", false)
+	if !errors.Is(err, ErrNoBrowsersConfigured) {
+		t.Fatalf("GetExtensions error = %v, want ErrNoBrowsersConfigured", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, `"netscape"`) || !strings.Contains(msg, "Chrome, Edge") {
+		t.Errorf("error %q doesn't name the unknown browser and the valid choices", msg)
+	}
+}
+
+func TestAddBrowser(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "Brave"},
+		{name: "Opera_GX2"},
+		{name: "", wantErr: true},
+		{name: "chrome", wantErr: true}, // names are case-insensitive, like table names
+		{name: "Brave Browser", wantErr: true},
+		{name: "Opera-GX", wantErr: true},
+		{name: "2Browser", wantErr: true},
+		{name: "_private", wantErr: true},
+		{name: "Vivaldi\"", wantErr: true},
+	}
+	for _, tt := range tests {
+		bi := NewBrowserInventory()
+		err := bi.AddBrowser(BrowserConfig{Name: tt.name, Scanner: ScannerChromium})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("AddBrowser(%q) error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if _, ok := bi.Config(tt.name); !tt.wantErr && !ok {
+			t.Errorf("AddBrowser(%q) didn't add the browser", tt.name)
+		}
+	}
+
+	// Every built-in browser has a valid name
+	for _, name := range NewBrowserInventory().BrowserNames() {
+		if !ValidBrowserName(name) {
+			t.Errorf("built-in browser %q has an invalid name", name)
+		}
+	}
+}
@@ -1,11 +1,19 @@
 package browsers
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func (bi *BrowserInventory) getChromiumExtensions(basePath string, config BrowserConfig, debug bool) ([]Extension, error) {
@@ -13,43 +21,46 @@ func (bi *BrowserInventory) getChromiumExtensions(basePath string, config Browse
 	if _, err := os.Stat(profileBase); os.IsNotExist(err) {
 		return nil, fmt.Errorf("profile base directory not found at %s", profileBase)
 	}
+	profileBase = resolveDir(profileBase, debug)
+	entries, err := readUserDataDir(profileBase)
+	if err != nil {
+		// Listed as skipped, but with nothing readable the browser has failed
+		bi.skipPermissionDenied(err, debug)
+		return nil, fmt.Errorf("failed to read profile directory: %w", err)
+	}
 
-	profileNames := make(map[string]string)
-	localStatePath := filepath.Join(profileBase, "Local State")
-	if data, err := os.ReadFile(localStatePath); err == nil {
-		var localState struct {
-			Profile struct {
-				InfoCache map[string]struct {
-					Name string `json:"name"`
-				} `json:"info_cache"`
-			} `json:"profile"`
-		}
-		if err := json.Unmarshal(data, &localState); err == nil {
-			for dir, info := range localState.Profile.InfoCache {
-				profileNames[dir] = info.Name
-			}
+	// Some builds keep the profiles under Snapshots/<version> instead; that
+	// layout is only used when the standard one is absent
+	if !bi.hasChromiumProfile(profileBase, entries) {
+		if snapshot, ok := latestChromiumSnapshot(profileBase); ok {
 			if debug {
-				fmt.Printf("Loaded profile names from Local State: %v\n", profileNames)
+				fmt.Printf("Note: No profiles in %s, using snapshot %s\n", profileBase, snapshot)
+			}
+			profileBase = snapshot
+			entries, err = readUserDataDir(profileBase)
+			if err != nil {
+				bi.skipPermissionDenied(err, debug)
+				return nil, fmt.Errorf("failed to read profile directory: %w", err)
 			}
-		} else if debug {
-			fmt.Printf("Warning: Failed to parse Local State at %s: %v\n", localStatePath, err)
 		}
-	} else if debug {
-		fmt.Printf("Note: Local State not found at %s, using directory names\n", localStatePath)
 	}
 
-	entries, err := os.ReadDir(profileBase)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read profile directory: %v", err)
+	profileNames := bi.loadChromiumProfileNames(profileBase, debug)
+	var activeProfile string
+	if bi.Options.ActiveProfileOnly {
+		activeProfile = chromiumActiveProfile(profileBase)
+		if debug {
+			fmt.Printf("Debug: Scanning only the active profile %s\n", activeProfile)
+		}
 	}
 
-	var allExtensions []Extension
+	var jobs []profileJob
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		if !isDirEntry(profileBase, entry) {
 			continue
 		}
 		profileDir := entry.Name()
-		if profileDir != "Default" && !strings.HasPrefix(profileDir, "Profile") {
+		if !bi.isChromiumProfileDir(profileDir) || (activeProfile != "" && profileDir != activeProfile) {
 			continue
 		}
 
@@ -58,83 +69,833 @@ func (bi *BrowserInventory) getChromiumExtensions(basePath string, config Browse
 			profileName = profileDir
 		}
 
-		extensionsPath := filepath.Join(profileBase, profileDir, "Extensions")
-		if _, err := os.Stat(extensionsPath); os.IsNotExist(err) {
-			if debug {
-				fmt.Printf("Note: Extensions directory not found at %s, skipping profile %s\n", extensionsPath, profileName)
+		jobs = append(jobs, profileJob{
+			key: filepath.Join(profileBase, profileDir),
+			scan: func() ([]Extension, error) {
+				return bi.scanChromiumProfile(profileBase, profileDir, profileName, config, debug)
+			},
+		})
+	}
+
+	allExtensions, err := bi.runProfileScans(config.Name, jobs, debug)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(allExtensions) == 0 {
+		if debug {
+			fmt.Printf("Note: No extensions found across profiles in %s\n", profileBase)
+		}
+	}
+
+	return allExtensions, nil
+}
+
+// hasChromiumProfile reports whether any of a User Data directory's entries
+// is a profile directory that would be scanned
+func (bi *BrowserInventory) hasChromiumProfile(profileBase string, entries []os.DirEntry) bool {
+	for _, entry := range entries {
+		if isDirEntry(profileBase, entry) && bi.isChromiumProfileDir(entry.Name()) {
+			return true
+		}
+	}
+	return false
+}
+
+// latestChromiumSnapshot returns the newest Snapshots/<version> directory
+// under a User Data directory, by version number, or false if there is none
+func latestChromiumSnapshot(profileBase string) (string, bool) {
+	snapshots := filepath.Join(profileBase, "Snapshots")
+	entries, err := readUserDataDir(snapshots)
+	if err != nil {
+		return "", false
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if isDirEntry(snapshots, entries[i]) {
+			return filepath.Join(snapshots, entries[i].Name()), true
+		}
+	}
+	return "", false
+}
+
+// loadChromiumProfileNames maps profile directories to their display names
+// from Local State. A missing or unreadable file yields an empty map.
+func (bi *BrowserInventory) loadChromiumProfileNames(profileBase string, debug bool) map[string]string {
+	profileNames := make(map[string]string)
+	localStatePath := filepath.Join(profileBase, "Local State")
+	var localState struct {
+		Profile struct {
+			InfoCache map[string]struct {
+				Name string `json:"name"`
+			} `json:"info_cache"`
+		} `json:"profile"`
+	}
+	if err := readJSONFile(localStatePath, &localState); err == nil {
+		for dir, info := range localState.Profile.InfoCache {
+			profileNames[dir] = info.Name
+		}
+		if debug {
+			fmt.Printf("Loaded profile names from Local State: %v\n", profileNames)
+		}
+	} else if os.IsNotExist(err) {
+		if debug {
+			fmt.Printf("Note: Local State not found at %s, using directory names\n", localStatePath)
+		}
+	} else {
+		if debug {
+			fmt.Printf("Warning: Failed to read Local State at %s: %v\n", localStatePath, err)
+		}
+		bi.recordFileError(localStatePath, err)
+	}
+	return profileNames
+}
+
+// chromiumActiveProfile returns the directory of the last used profile from
+// Local State's profile.last_used, then profile.last_active_profiles, falling
+// back to Default
+func chromiumActiveProfile(profileBase string) string {
+	var localState struct {
+		Profile struct {
+			LastUsed           string   `json:"last_used"`
+			LastActiveProfiles []string `json:"last_active_profiles"`
+		} `json:"profile"`
+	}
+	if err := readJSONFile(filepath.Join(profileBase, "Local State"), &localState); err == nil {
+		if localState.Profile.LastUsed != "" {
+			return localState.Profile.LastUsed
+		}
+		if len(localState.Profile.LastActiveProfiles) > 0 {
+			return localState.Profile.LastActiveProfiles[0]
+		}
+	}
+	return "Default"
+}
+
+// readUserDataDir lists a User Data directory in natural order, so profiles
+// come out as Default, Profile 1, Profile 2, ..., Profile 10 rather than with
+// Profile 10 before Profile 2
+func readUserDataDir(dir string) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(dir)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return naturalLess(entries[i].Name(), entries[j].Name())
+	})
+	return entries, err
+}
+
+// naturalLess compares strings with runs of digits ordered by numeric value
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da != "" && db != "" {
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
 			}
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[len(da):], b[len(db):]
 			continue
 		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// leadingDigits returns the run of ASCII digits at the start of s
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
+
+// chromiumSystemProfiles are the browser's own profile directories, which
+// have no extensions of the user's choosing and are skipped unless
+// Options.IncludeSystemProfiles is set
+var chromiumSystemProfiles = []string{"System Profile", "Guest Profile"}
 
+// CompileProfilePattern compiles a profile directory pattern for
+// Options.ProfilePattern, anchored so that it must match the whole directory
+// name: "Work" selects Work but not "Work Old"
+func CompileProfilePattern(pattern string) (*regexp.Regexp, error) {
+	// Compile it alone first, so a pattern such as "a)|(b" can't close the
+	// anchoring group and match part of a name
+	if _, err := regexp.Compile(pattern); err != nil {
+		return nil, err
+	}
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// isChromiumProfileDir reports whether a User Data entry is a profile
+// directory: one matching Options.ProfilePattern when set, otherwise Default
+// or a name starting with Profile. The system profiles are added with
+// Options.IncludeSystemProfiles.
+func (bi *BrowserInventory) isChromiumProfileDir(name string) bool {
+	if bi.Options.IncludeSystemProfiles && slices.Contains(chromiumSystemProfiles, name) {
+		return true
+	}
+	if bi.Options.ProfilePattern != nil {
+		return bi.Options.ProfilePattern.MatchString(name)
+	}
+	return name == "Default" || strings.HasPrefix(name, "Profile")
+}
+
+// scanChromiumProfile reads the extensions installed in one Chromium profile:
+// those in its Extensions directory and unpacked extensions loaded from
+// elsewhere, whose paths are recorded in Preferences
+func (bi *BrowserInventory) scanChromiumProfile(profileBase, profileDir, profileName string, config BrowserConfig, debug bool) ([]Extension, error) {
+	if bi.skipUnreadableDir(filepath.Join(profileBase, profileDir), debug) {
+		return nil, nil
+	}
+	settings := bi.loadChromiumExtensionSettings(filepath.Join(profileBase, profileDir), debug)
+
+	// One buffer is reused for every manifest in the profile
+	var buf bytes.Buffer
+	var allExtensions []Extension
+
+	extensionsPath := filepath.Join(profileBase, profileDir, "Extensions")
+	if _, err := os.Stat(extensionsPath); os.IsNotExist(err) {
+		if debug {
+			fmt.Printf("Note: Extensions directory not found at %s, skipping installed extensions for profile %s\n", extensionsPath, profileName)
+		}
+	} else {
+		extensionsPath = resolveDir(extensionsPath, debug)
 		if debug {
 			fmt.Printf("Resolved extensions path for profile %s: %s\n", profileName, extensionsPath)
 		}
+		installed, err := bi.scanChromiumExtensionsDir(&buf, extensionsPath, profileName, settings, config, debug)
+		if err != nil {
+			return nil, err
+		}
+		allExtensions = installed
+	}
 
-		dirs, err := os.ReadDir(extensionsPath)
+	// Sorted so unpacked extensions are reported in a stable order
+	var unpackedIDs []string
+	for id, s := range settings {
+		if s.isUnpacked() && filepath.IsAbs(s.Path) {
+			unpackedIDs = append(unpackedIDs, id)
+		}
+	}
+	sort.Strings(unpackedIDs)
+	for _, id := range unpackedIDs {
+		dir := settings[id].Path
+		if debug {
+			fmt.Printf("Debug: Reading unpacked extension %s from %s\n", id, dir)
+		}
+		if ext, ok := bi.readChromiumExtension(&buf, dir, id, profileName, settings, config, debug); ok {
+			allExtensions = append(allExtensions, ext)
+		}
+	}
+
+	markProfileDuplicates(allExtensions, debug)
+	return allExtensions, nil
+}
+
+// scanChromiumExtensionsDir reads every <id>/<version> directory under a
+// profile's Extensions directory
+func (bi *BrowserInventory) scanChromiumExtensionsDir(buf *bytes.Buffer, extensionsPath, profileName string, settings map[string]chromiumExtensionSettings, config BrowserConfig, debug bool) ([]Extension, error) {
+	dirs, err := os.ReadDir(extensionsPath)
+	if bi.skipPermissionDenied(err, debug) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extensions directory %s: %v", extensionsPath, err)
+	}
+
+	var allExtensions []Extension
+	for _, dir := range dirs {
+		if !isDirEntry(extensionsPath, dir) {
+			continue
+		}
+		// Chromium writes IDs in lowercase; a differently cased directory is
+		// read under the lowercase ID so a stray copy shows up as a duplicate
+		extensionID := strings.ToLower(dir.Name())
+		extensionDir := filepath.Join(extensionsPath, dir.Name())
+		if !isChromiumExtensionID(extensionID) {
+			if debug {
+				fmt.Printf("Note: Skipping non-extension directory %s\n", extensionDir)
+			}
+			continue
+		}
+		if extensionID != dir.Name() && debug {
+			fmt.Printf("Warning: Extension directory %s isn't lowercase\n", extensionDir)
+		}
+		versions, err := os.ReadDir(extensionDir)
+		if bi.skipPermissionDenied(err, debug) {
+			continue
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to read extensions directory %s: %v", extensionsPath, err)
+			if debug {
+				fmt.Printf("Warning: Failed to read version directory for %s: %v\n", extensionID, err)
+			}
+			continue
 		}
 
-		for _, dir := range dirs {
-			if !dir.IsDir() {
+		for _, ver := range versions {
+			if !isDirEntry(extensionDir, ver) {
 				continue
 			}
-			extensionID := dir.Name()
-			versions, err := os.ReadDir(filepath.Join(extensionsPath, extensionID))
-			if err != nil {
+			versionDir := filepath.Join(extensionDir, ver.Name())
+			ext, ok := bi.readChromiumExtension(buf, versionDir, extensionID, profileName, settings, config, debug)
+			if !ok {
+				continue
+			}
+			if ext.MarkedForDeletion && !bi.Options.IncludeDisabledFiles {
 				if debug {
-					fmt.Printf("Warning: Failed to read version directory for %s: %v\n", extensionID, err)
+					fmt.Printf("Note: Skipping %s, marked for deletion\n", versionDir)
 				}
 				continue
 			}
+			allExtensions = append(allExtensions, ext)
+		}
+	}
+	return allExtensions, nil
+}
 
-			for _, ver := range versions {
-				if !ver.IsDir() {
-					continue
-				}
-				manifestPath := filepath.Join(extensionsPath, extensionID, ver.Name(), config.ManifestFile)
-				data, err := os.ReadFile(manifestPath)
-				if err != nil {
-					if debug {
-						fmt.Printf("Warning: Failed to read manifest %s: %v\n", manifestPath, err)
-					}
-					continue
-				}
+// readChromiumExtension reads the extension whose manifest is in dir, applying
+// its Preferences settings. Unreadable manifests are recorded as file errors
+// and reported as false.
+func (bi *BrowserInventory) readChromiumExtension(buf *bytes.Buffer, dir, extensionID, profileName string, settings map[string]chromiumExtensionSettings, config BrowserConfig, debug bool) (Extension, bool) {
+	manifestPath := filepath.Join(dir, config.ManifestFile)
+	data, readPath, err := readManifestFile(buf, manifestPath)
+	if err != nil {
+		if debug {
+			fmt.Printf("Warning: Failed to read manifest %s: %v\n", manifestPath, err)
+		}
+		bi.recordFileError(manifestPath, err)
+		return Extension{}, false
+	}
+	if readPath != manifestPath && debug {
+		fmt.Printf("Note: Using compressed manifest %s\n", readPath)
+	}
 
-				var manifest struct {
-					Name          string `json:"name"`
-					Version       string `json:"version"`
-					DefaultLocale string `json:"default_locale"`
-				}
-				if err := json.Unmarshal(data, &manifest); err != nil {
+	var manifest struct {
+		Name            string            `json:"name"`
+		ShortName       string            `json:"short_name"`
+		Version         string            `json:"version"`
+		VersionName     string            `json:"version_name"`
+		Author          manifestAuthor    `json:"author"`
+		MinimumVersion  string            `json:"minimum_chrome_version"`
+		DefaultLocale   string            `json:"default_locale"`
+		Permissions     []json.RawMessage `json:"permissions"`
+		HostPermissions []string          `json:"host_permissions"`
+		UpdateURL       string            `json:"update_url"`
+		Key             string            `json:"key"`
+		Action          manifestAction    `json:"action"`
+		BrowserAction   manifestAction    `json:"browser_action"`
+		PageAction      manifestAction    `json:"page_action"`
+		Theme           json.RawMessage   `json:"theme"`
+	}
+	if err := bi.parseManifest(data, &manifest, readPath, debug); err != nil {
+		if debug {
+			fmt.Printf("Warning: Failed to parse manifest %s: %v\n", readPath, err)
+		}
+		bi.recordFileError(readPath, err)
+		return Extension{}, false
+	}
+
+	// Only placeholders need the _locales lookup; plain and empty names
+	// are used as-is
+	resolvedName, shortName := manifest.Name, manifest.ShortName
+	if strings.HasPrefix(resolvedName, "__MSG_") || strings.HasPrefix(shortName, "__MSG_") {
+		actions := []manifestAction{manifest.Action, manifest.BrowserAction, manifest.PageAction}
+		resolve := func() (string, string, bool) {
+			return bi.resolveNames(resolvedName, shortName, actions, dir, manifest.DefaultLocale, extensionID, debug)
+		}
+		// An unpacked extension's _locales can change without a version bump
+		// while it's being developed, so its names are always read afresh
+		if settings[extensionID].isUnpacked() {
+			resolvedName, shortName, _ = resolve()
+		} else {
+			resolvedName, shortName = bi.cachedNames(extensionID, manifest.Version, resolve, debug)
+		}
+	}
+
+	author := string(manifest.Author)
+	if strings.HasPrefix(author, "__MSG_") {
+		author, _ = bi.resolveMessage(author, dir, manifest.DefaultLocale, debug)
+	}
+
+	addonType := TypeExtension
+	if len(manifest.Theme) > 0 {
+		addonType = TypeTheme
+	}
+
+	enabled, disabledReason, installSource := true, "", ""
+	builtin := config.isBuiltinID(extensionID) || strings.Contains(manifest.UpdateURL, componentUpdaterPath)
+	markedForDeletion, incognito, installedBy := false, false, ""
+	var installedAt, updatedAt *time.Time
+	if s, ok := settings[extensionID]; ok {
+		enabled, disabledReason = s.status()
+		incognito = s.Incognito
+		installedBy = s.installedBy()
+		installedAt, updatedAt = s.times()
+		builtin = builtin || s.isComponent()
+		markedForDeletion = s.markedForDeletion(extensionID, filepath.Base(dir))
+		if s.isUnpacked() {
+			installSource = InstallSourceUnpacked
+		}
+	}
+
+	// The version directory is written when the version is installed
+	if installedAt == nil || updatedAt == nil {
+		if info, err := os.Stat(dir); err == nil {
+			mtime := info.ModTime().UTC().Truncate(time.Microsecond)
+			if installedAt == nil {
+				installedAt = &mtime
+			}
+			if updatedAt == nil {
+				updatedAt = &mtime
+			}
+		}
+	}
+
+	permissions, hostPermissions := splitPermissions(manifest.Permissions)
+	hostPermissions = append(hostPermissions, manifest.HostPermissions...)
+
+	var computedID string
+	if bi.Options.VerifyIDs && manifest.Key != "" {
+		computedID, err = chromiumIDFromKey(manifest.Key)
+		if err != nil {
+			if debug {
+				fmt.Printf("Warning: Failed to derive ID from key in %s: %v\n", readPath, err)
+			}
+			bi.recordFileError(readPath, err)
+		} else if computedID != extensionID && debug {
+			fmt.Printf("Warning: %s has key for ID %s\n", dir, computedID)
+		}
+	}
+
+	// data is the shared buffer, so rawJSON's copy is what outlives it
+	var rawManifest json.RawMessage
+	if bi.Options.RawManifest {
+		rawManifest = rawJSON(data)
+	}
+
+	var verified *bool
+	var modifiedFiles []string
+	if bi.Options.VerifyContents {
+		verified, modifiedFiles, err = verifyChromiumContents(dir)
+		if err != nil {
+			if debug {
+				fmt.Printf("Warning: Failed to read content hashes in %s: %v\n", dir, err)
+			}
+			bi.recordFileError(filepath.Join(dir, "_metadata"), err)
+		} else if verified != nil && !*verified && debug {
+			fmt.Printf("Warning: %s has modified files: %s\n", dir, strings.Join(modifiedFiles, ", "))
+		}
+	}
+
+	return Extension{
+		Name:              resolvedName,
+		ShortName:         shortName,
+		Version:           manifest.Version,
+		VersionName:       manifest.VersionName,
+		MinBrowserVersion: manifest.MinimumVersion,
+		ID:                extensionID,
+		Author:            author,
+		Type:              addonType,
+		Enabled:           enabled,
+		DisabledReason:    disabledReason,
+		Browser:           config.Name,
+		Profile:           profileName,
+		Permissions:       permissions,
+		HostPermissions:   hostPermissions,
+		Path:              dir,
+		Builtin:           builtin,
+		InstallSource:     installSource,
+		InstalledBy:       installedBy,
+		InstalledAt:       installedAt,
+		UpdatedAt:         updatedAt,
+		IncognitoAllowed:  incognito,
+		RawManifest:       rawManifest,
+		UpdateURL:         manifest.UpdateURL,
+
+		MarkedForDeletion: markedForDeletion,
+		ComputedID:        computedID,
+		IDMismatch:        computedID != "" && computedID != extensionID,
+		Verified:          verified,
+		ModifiedFiles:     modifiedFiles,
+	}, true
+}
+
+// resolveNames resolves __MSG_ placeholders in the name and short name,
+// falling back to an action title for the name. It reports whether the name
+// was resolved.
+func (bi *BrowserInventory) resolveNames(name, shortName string, actions []manifestAction, versionDir, defaultLocale, id string, debug bool) (string, string, bool) {
+	ok := true
+	if strings.HasPrefix(name, "__MSG_") {
+		name, ok = bi.resolveMessage(name, versionDir, defaultLocale, debug)
+		if !ok {
+			// Fall back to the toolbar button title, MV3 first
+			for _, action := range actions {
+				if title, found := bi.actionTitle(action, versionDir, defaultLocale, debug); found {
 					if debug {
-						fmt.Printf("Warning: Failed to parse manifest %s: %v\n", manifestPath, err)
+						fmt.Printf("Debug: Using action title %q as name for %s\n", title, id)
 					}
-					continue
-				}
-
-				resolvedName := manifest.Name
-				if strings.HasPrefix(resolvedName, "__MSG_") {
-					resolvedName = resolveMessage(resolvedName, filepath.Join(extensionsPath, extensionID, ver.Name()), manifest.DefaultLocale, debug)
+					name, ok = title, true
+					break
 				}
-
-				allExtensions = append(allExtensions, Extension{
-					Name:    resolvedName,
-					Version: manifest.Version,
-					ID:      extensionID,
-					Enabled: true,
-					Browser: config.Name,
-					Profile: profileName,
-				})
 			}
 		}
 	}
+	if strings.HasPrefix(shortName, "__MSG_") {
+		shortName, _ = bi.resolveMessage(shortName, versionDir, defaultLocale, debug)
+	}
+	return name, shortName, ok
+}
 
-	if len(allExtensions) == 0 {
+// cachedNames returns the names of an extension version from
+// Options.NameCache, calling resolve and caching its result on a miss. Names
+// that couldn't be resolved aren't cached. Cache errors only cost the lookup.
+func (bi *BrowserInventory) cachedNames(id, version string, resolve func() (string, string, bool), debug bool) (string, string) {
+	cache := bi.Options.NameCache
+	if cache == nil {
+		name, shortName, _ := resolve()
+		return name, shortName
+	}
+	name, shortName, ok, err := cache.LookupNames(id, version)
+	if err != nil && debug {
+		fmt.Printf("Warning: Failed to read cached names for %s %s: %v\n", id, version, err)
+	}
+	if ok {
 		if debug {
-			fmt.Printf("Note: No extensions found across profiles in %s\n", profileBase)
+			fmt.Printf("Debug: Using cached names for %s %s\n", id, version)
 		}
+		return name, shortName
 	}
 
-	return allExtensions, nil
+	name, shortName, resolved := resolve()
+	if resolved {
+		if err := cache.SaveNames(id, version, name, shortName); err != nil && debug {
+			fmt.Printf("Warning: Failed to cache names for %s %s: %v\n", id, version, err)
+		}
+	}
+	return name, shortName
+}
+
+// parseManifest decodes a manifest, transcoding UTF-16 and ignoring a BOM. With
+// Options.Lenient a manifest with trailing commas is retried once they are
+// removed; the original error is returned if that fails too.
+func (bi *BrowserInventory) parseManifest(data []byte, v any, path string, debug bool) error {
+	data = decodeText(data)
+	err := json.Unmarshal(data, v)
+	if err == nil || !bi.Options.Lenient {
+		return err
+	}
+	if json.Unmarshal(stripTrailingCommas(data), v) != nil {
+		return err
+	}
+	if debug {
+		fmt.Printf("Note: Parsed %s leniently after removing trailing commas\n", path)
+	}
+	return nil
+}
+
+// manifestAction is the action, browser_action or page_action manifest key
+type manifestAction struct {
+	DefaultTitle string `json:"default_title"`
+}
+
+// manifestAuthor is the manifest's author, given either as a string or, as
+// the Web Store documents for MV3, as an object with an email. Other shapes
+// are ignored rather than failing the manifest.
+type manifestAuthor string
+
+func (a *manifestAuthor) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = manifestAuthor(strings.TrimSpace(s))
+		return nil
+	}
+	var obj struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil
+	}
+	name, email := strings.TrimSpace(obj.Name), strings.TrimSpace(obj.Email)
+	switch {
+	case name != "" && email != "":
+		*a = manifestAuthor(name + " <" + email + ">")
+	case name != "":
+		*a = manifestAuthor(name)
+	default:
+		*a = manifestAuthor(email)
+	}
+	return nil
+}
+
+// actionTitle returns the action's default_title, resolving a placeholder if
+// needed. It reports false when the title is missing or unresolvable.
+func (bi *BrowserInventory) actionTitle(action manifestAction, versionDir, defaultLocale string, debug bool) (string, bool) {
+	title := strings.TrimSpace(action.DefaultTitle)
+	if title == "" {
+		return "", false
+	}
+	if strings.HasPrefix(title, "__MSG_") {
+		return bi.resolveMessage(title, versionDir, defaultLocale, debug)
+	}
+	return title, true
+}
+
+// isChromiumExtensionID reports whether name looks like a Chromium extension
+// ID: 32 characters in the range a-p
+func isChromiumExtensionID(name string) bool {
+	if len(name) != 32 {
+		return false
+	}
+	for _, c := range name {
+		if c < 'a' || c > 'p' {
+			return false
+		}
+	}
+	return true
+}
+
+// chromiumIDFromKey derives an extension ID from a manifest key: the first 16
+// bytes of the SHA-256 of the DER-encoded public key, written as hex with the
+// digits 0-f mapped to a-p
+func chromiumIDFromKey(key string) (string, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(key), ""))
+	if err != nil {
+		return "", fmt.Errorf("invalid manifest key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	id := make([]byte, 32)
+	for i, b := range sum[:16] {
+		id[2*i] = 'a' + b>>4
+		id[2*i+1] = 'a' + b&0x0f
+	}
+	return string(id), nil
+}
+
+// splitPermissions separates API permissions from host match patterns, which
+// Manifest V2 mixes in the same list. Non-string entries are ignored.
+func splitPermissions(raw []json.RawMessage) (permissions, hosts []string) {
+	for _, r := range raw {
+		var p string
+		if err := json.Unmarshal(r, &p); err != nil {
+			continue
+		}
+		if isHostPattern(p) {
+			hosts = append(hosts, p)
+		} else {
+			permissions = append(permissions, p)
+		}
+	}
+	return permissions, hosts
+}
+
+// isHostPattern reports whether a permission is a host match pattern
+func isHostPattern(p string) bool {
+	return p == "<all_urls>" || strings.Contains(p, "://")
+}
+
+// Chromium disable_reasons bit values (extensions/common/disable_reason.h)
+const (
+	chromiumDisableUserAction             = 1 << 0
+	chromiumDisableGreylist               = 1 << 9
+	chromiumDisableCorrupted              = 1 << 10
+	chromiumDisableUpdateRequiredByPolicy = 1 << 14
+	chromiumDisableBlockedByPolicy        = 1 << 16
+	chromiumDisablePolicyMask             = chromiumDisableUpdateRequiredByPolicy | chromiumDisableBlockedByPolicy
+	chromiumExtensionStateDisabled        = 0
+	chromiumExtensionStateUninstalled     = 2 // EXTERNAL_EXTENSION_UNINSTALLED
+	chromiumBlocklistStateNotBlocklisted  = 0
+)
+
+// Chromium Manifest::Location values recorded in Preferences
+const (
+	chromiumLocationExternalPref         = 2 // Preinstall JSON file
+	chromiumLocationExternalRegistry     = 3 // Windows registry preinstall key
+	chromiumLocationUnpacked             = 4 // Load unpacked
+	chromiumLocationComponent            = 5
+	chromiumLocationExternalPrefDownload = 6 // Preinstall JSON file with an update URL
+	chromiumLocationPolicyDownload       = 7 // ExtensionInstallForcelist
+	chromiumLocationCommandLine          = 8 // --load-extension
+	chromiumLocationPolicy               = 9
+	chromiumLocationExternalComponent    = 10
+)
+
+// componentUpdaterPath marks update URLs served by a browser's component
+// updater rather than its web store, e.g. Edge's
+// https://edge.microsoft.com/componentupdater/api/v1/update
+const componentUpdaterPath = "/componentupdater/"
+
+// chromiumExtensionSettings holds the per-extension entries from Preferences
+type chromiumExtensionSettings struct {
+	State          *int            `json:"state"`
+	DisableReasons json.RawMessage `json:"disable_reasons"`
+	Blacklist      bool            `json:"blacklist"`
+	BlacklistState int             `json:"blacklist_state"`
+	Location       int             `json:"location"`
+	Incognito      bool            `json:"incognito"`
+	// Set when the extension wasn't installed by the user
+	ByDefault   bool `json:"was_installed_by_default"`
+	ByOEM       bool `json:"was_installed_by_oem"`
+	ByCustodian bool `json:"was_installed_by_custodian"`
+	// Path is the installed version directory relative to Extensions, e.g.
+	// <id>/1.2.3_0, or an absolute path for unpacked extensions
+	Path string `json:"path"`
+	// Microseconds since 1601-01-01 UTC, as decimal strings. install_time
+	// moves with every update; newer versions keep the original install in
+	// first_install_time and add last_update_time.
+	InstallTime      string `json:"install_time"`
+	FirstInstallTime string `json:"first_install_time"`
+	LastUpdateTime   string `json:"last_update_time"`
+}
+
+// times returns when the extension was installed and last updated, as far
+// as Preferences records them
+func (s chromiumExtensionSettings) times() (installedAt, updatedAt *time.Time) {
+	install, hasInstall := chromiumTime(s.InstallTime)
+	if first, ok := chromiumTime(s.FirstInstallTime); ok {
+		installedAt = &first
+	} else if hasInstall {
+		installedAt = &install
+	}
+	if last, ok := chromiumTime(s.LastUpdateTime); ok {
+		updatedAt = &last
+	} else if hasInstall {
+		updatedAt = &install
+	}
+	return installedAt, updatedAt
+}
+
+// chromiumEpochOffset is the number of microseconds from Chromium's time base,
+// 1601-01-01 UTC (the Windows FILETIME epoch), to the Unix epoch
+const chromiumEpochOffset = 11644473600 * 1000000
+
+// chromiumTime converts a Preferences timestamp, microseconds since
+// 1601-01-01 UTC as a decimal string, to a time. Empty, zero, and malformed
+// values report false.
+func chromiumTime(v string) (time.Time, bool) {
+	us, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || us <= 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMicro(us - chromiumEpochOffset).UTC(), true
+}
+
+// isComponent reports whether the browser itself installed the extension
+func (s chromiumExtensionSettings) isComponent() bool {
+	return s.Location == chromiumLocationComponent || s.Location == chromiumLocationExternalComponent
+}
+
+// installedBy returns who installed the extension when it wasn't the user,
+// from its Preferences location and was_installed_by_* flags
+func (s chromiumExtensionSettings) installedBy() string {
+	switch {
+	case s.Location == chromiumLocationPolicy || s.Location == chromiumLocationPolicyDownload:
+		return InstalledByPolicy
+	case s.ByCustodian:
+		return InstalledByCustodian
+	case s.ByOEM:
+		return InstalledByOEM
+	case s.ByDefault:
+		return InstalledByDefault
+	case s.Location == chromiumLocationExternalPref || s.Location == chromiumLocationExternalRegistry || s.Location == chromiumLocationExternalPrefDownload:
+		return InstalledByExternal
+	}
+	return ""
+}
+
+// isUnpacked reports whether the extension was loaded from a directory in
+// developer mode rather than installed from a package
+func (s chromiumExtensionSettings) isUnpacked() bool {
+	return s.Location == chromiumLocationUnpacked || s.Location == chromiumLocationCommandLine
+}
+
+// markedForDeletion reports whether the version directory is pending removal:
+// the extension was uninstalled but its files remain, or the directory holds a
+// version superseded by an update and not yet garbage collected
+func (s chromiumExtensionSettings) markedForDeletion(id, version string) bool {
+	if s.State != nil && *s.State == chromiumExtensionStateUninstalled {
+		return true
+	}
+	if s.Path == "" || filepath.IsAbs(s.Path) {
+		return false
+	}
+	return filepath.Clean(filepath.FromSlash(s.Path)) != filepath.Join(id, version)
+}
+
+// disableReasons returns the disable_reasons bitmask, which older builds store
+// as an integer and newer builds as a list of reason values
+func (s chromiumExtensionSettings) disableReasons() int {
+	if len(s.DisableReasons) == 0 {
+		return 0
+	}
+	var mask int
+	if err := json.Unmarshal(s.DisableReasons, &mask); err == nil {
+		return mask
+	}
+	var reasons []int
+	if err := json.Unmarshal(s.DisableReasons, &reasons); err == nil {
+		for _, r := range reasons {
+			mask |= r
+		}
+	}
+	return mask
+}
+
+// status derives the enabled state and disabled reason from the settings
+func (s chromiumExtensionSettings) status() (bool, string) {
+	reasons := s.disableReasons()
+	blocklisted := s.Blacklist || s.BlacklistState != chromiumBlocklistStateNotBlocklisted
+	disabled := reasons != 0 || blocklisted || (s.State != nil && *s.State == chromiumExtensionStateDisabled)
+	if !disabled {
+		return true, ""
+	}
+
+	switch {
+	case reasons&chromiumDisablePolicyMask != 0:
+		return false, DisabledReasonPolicy
+	case blocklisted || reasons&chromiumDisableGreylist != 0:
+		return false, DisabledReasonBlocklist
+	case reasons&chromiumDisableCorrupted != 0:
+		return false, DisabledReasonCorrupt
+	case reasons&chromiumDisableUserAction != 0 || reasons == 0:
+		return false, DisabledReasonUser
+	default:
+		return false, DisabledReasonOther
+	}
+}
+
+// loadChromiumExtensionSettings reads extensions.settings from Preferences and
+// Secure Preferences, with Secure Preferences taking precedence
+func (bi *BrowserInventory) loadChromiumExtensionSettings(profilePath string, debug bool) map[string]chromiumExtensionSettings {
+	settings := make(map[string]chromiumExtensionSettings)
+	for _, file := range []string{"Preferences", "Secure Preferences"} {
+		prefsPath := filepath.Join(profilePath, file)
+		var prefs struct {
+			Extensions struct {
+				Settings map[string]chromiumExtensionSettings `json:"settings"`
+			} `json:"extensions"`
+		}
+		if err := readJSONFile(prefsPath, &prefs); err != nil {
+			if os.IsNotExist(err) {
+				if debug {
+					fmt.Printf("Note: %s not found at %s\n", file, prefsPath)
+				}
+			} else if !bi.skipPermissionDenied(err, debug) {
+				if debug {
+					fmt.Printf("Warning: Failed to read %s: %v\n", prefsPath, err)
+				}
+				bi.recordFileError(prefsPath, err)
+			}
+			continue
+		}
+		for id, s := range prefs.Extensions.Settings {
+			settings[id] = s
+		}
+	}
+	return settings
 }
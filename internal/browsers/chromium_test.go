@@ -0,0 +1,385 @@
+package browsers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSyntheticProfile fills a Default profile under userData with n
+// extensions of m versions each. Every name is a __MSG_ placeholder resolved
+// from a non-English default_locale, the slow path of a scan.
+func writeSyntheticProfile(tb testing.TB, userData string, n, m int) {
+	tb.Helper()
+	for i := 0; i < n; i++ {
+		id := syntheticExtensionID(i)
+		for v := 0; v < m; v++ {
+			dir := filepath.Join(userData, "Default", "Extensions", id, fmt.Sprintf("1.%d_0", v))
+			writeFile(tb, filepath.Join(dir, "manifest.json"), fmt.Sprintf(`{
+  "manifest_version": 3,
+  "name": "__MSG_extName__",
+  "short_name": "__MSG_extShortName__",
+  "version": "1.%d",
+  "default_locale": "fr",
+  "permissions": ["storage", "tabs"],
+  "host_permissions": ["https://*.example.com/*"]
+}`, v))
+			writeFile(tb, filepath.Join(dir, "_locales", "fr", "messages.json"), fmt.Sprintf(`{
+  "extName": {"message": "Extension %d"},
+  "extShortName": {"message": "Ext %d"}
+}`, i, i))
+			writeFile(tb, filepath.Join(dir, "_locales", "en", "messages.json"), `{}`)
+		}
+	}
+}
+
+// syntheticExtensionID formats i as a valid extension ID by writing its hex
+// digits in the a-p alphabet Chromium uses
+func syntheticExtensionID(i int) string {
+	b := []byte(fmt.Sprintf("%032x", i))
+	for j, c := range b {
+		if c <= '9' {
+			b[j] = 'a' + c - '0'
+		} else {
+			b[j] = 'k' + c - 'a'
+		}
+	}
+	return string(b)
+}
+
+func BenchmarkGetChromiumExtensions(b *testing.B) {
+	for _, size := range []struct{ extensions, versions int }{
+		{100, 1},
+		{500, 2},
+	} {
+		b.Run(fmt.Sprintf("%dx%d", size.extensions, size.versions), func(b *testing.B) {
+			userData := b.TempDir()
+			writeSyntheticProfile(b, userData, size.extensions, size.versions)
+			config := BrowserConfig{Name: "Chrome", ManifestFile: "manifest.json"}
+			bi := NewBrowserInventory()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				exts, err := bi.getChromiumExtensions(filepath.Join(userData, "Default"), config, false)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(exts) != size.extensions*size.versions {
+					b.Fatalf("got %d extensions, want %d", len(exts), size.extensions*size.versions)
+				}
+			}
+		})
+	}
+}
+
+func TestIsChromiumExtensionID(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"aaaabbbbccccddddeeeeffffgggghhhh", true},
+		{"pjhljbkjcfhaehpdajpeadceelfacnap", true},
+		{"Temp", false},
+		{"temp", false},
+		{"", false},
+		{"aaaabbbbccccddddeeeeffffgggghhh", false},   // 31 characters
+		{"aaaabbbbccccddddeeeeffffgggghhhha", false}, // 33 characters
+		{"aaaabbbbccccddddeeeeffffgggghhhq", false},  // q is outside a-p
+		{"AAAABBBBCCCCDDDDEEEEFFFFGGGGHHHH", false},  // callers lowercase first
+	}
+	for _, tt := range tests {
+		if got := isChromiumExtensionID(tt.name); got != tt.want {
+			t.Errorf("isChromiumExtensionID(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestTempDirSkipped checks that the fixture's Extensions/Temp directory,
+// which holds a manifest like a half-unpacked download would, isn't reported
+func TestTempDirSkipped(t *testing.T) {
+	bi := newFixtureInventory(t, fixtureHome)
+	for _, e := range scanFixture(t, bi, "Chrome") {
+		if strings.EqualFold(e.ID, "temp") || strings.Contains(e.Path, "Temp") {
+			t.Errorf("Temp directory reported as extension %s at %s", e.ID, e.Path)
+		}
+	}
+	for _, fe := range bi.FileErrors() {
+		if strings.Contains(fe.Path, "Temp") {
+			t.Errorf("Temp directory read: %v", fe)
+		}
+	}
+}
+
+func TestCompileProfilePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"Testing", "Testing", true},
+		{"Testing", "Testing Old", false},
+		{"Testing", "My Testing", false},
+		{"Default|Profile .*", "Default", true},
+		{"Default|Profile .*", "Profile 10", true},
+		{"Default|Profile .*", "Old Default", false},
+		{"Default|Profile .*", "Profile", false},
+		{"Work.*", "Work", true},
+		{"Work.*", "Homework", false},
+		{"^Work$", "Work", true},
+	}
+	for _, tt := range tests {
+		re, err := CompileProfilePattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("CompileProfilePattern(%q): %v", tt.pattern, err)
+		}
+		bi := NewBrowserInventory()
+		bi.Options.ProfilePattern = re
+		if got := bi.isChromiumProfileDir(tt.name); got != tt.want {
+			t.Errorf("pattern %q matching %q = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompileProfilePatternInvalid(t *testing.T) {
+	for _, pattern := range []string{"Profile (", "[a-", "*", "a)|(b"} {
+		if _, err := CompileProfilePattern(pattern); err == nil {
+			t.Errorf("CompileProfilePattern(%q) succeeded, want an error", pattern)
+		}
+	}
+}
+
+// TestCustomProfileDirectory scans the fixture's Testing profile, which is
+// only found by name through a profile pattern
+func TestCustomProfileDirectory(t *testing.T) {
+	const testingID = "nnnnoooonnnnoooonnnnoooonnnnoooo"
+	found := func(exts []Extension) bool {
+		for _, ext := range exts {
+			if ext.ID == testingID {
+				return ext.Profile == "Testing"
+			}
+		}
+		return false
+	}
+
+	bi := newFixtureInventory(t, fixtureHome)
+	if found(scanFixture(t, bi, "chrome")) {
+		t.Errorf("%s from the Testing profile found without -profile-pattern", testingID)
+	}
+
+	re, err := CompileProfilePattern("Default|Profile .*|Testing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bi = newFixtureInventory(t, fixtureHome)
+	bi.Options.ProfilePattern = re
+	if !found(scanFixture(t, bi, "chrome")) {
+		t.Errorf("%s from the Testing profile not found with a profile pattern", testingID)
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Profile 9", "Profile 10", true},
+		{"Profile 10", "Profile 9", false},
+		{"Profile 2", "Profile 10", true},
+		{"Profile 10", "Profile 11", true},
+		{"Profile 1", "Profile 1", false},
+		{"Profile 007", "Profile 10", true},
+		{"Profile 1", "Profile 1a", true},
+		{"Profile 1", "Profile 2a", true},
+		{"Default", "Profile 1", true},
+		{"Profile", "Profile 1", true},
+		{"Profile 99999999999999999999", "Profile 100000000000000000000", true},
+	}
+	for _, tt := range tests {
+		if got := naturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestReadUserDataDirOrder(t *testing.T) {
+	userData := t.TempDir()
+	for _, name := range []string{"Profile 10", "Profile 9", "Profile 2", "Default", "Profile 1", "Profile 11"} {
+		if err := os.Mkdir(filepath.Join(userData, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := readUserDataDir(userData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	want := []string{"Default", "Profile 1", "Profile 2", "Profile 9", "Profile 10", "Profile 11"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readUserDataDir order = %q, want %q", got, want)
+	}
+}
+
+func TestChromiumTime(t *testing.T) {
+	tests := []struct {
+		v      string
+		want   time.Time
+		wantOK bool
+	}{
+		{"13303228800000000", time.Date(2022, 7, 25, 13, 20, 0, 0, time.UTC), true},
+		{"11644473600000000", time.Unix(0, 0).UTC(), true},
+		{"13303228800123456", time.Date(2022, 7, 25, 13, 20, 0, 123456000, time.UTC), true},
+		{"0", time.Time{}, false},
+		{"", time.Time{}, false},
+		{"-1", time.Time{}, false},
+		{"13303228800000000.5", time.Time{}, false},
+		{"1.3303228800000000e16", time.Time{}, false},
+		{"yesterday", time.Time{}, false},
+		{" 13303228800000000", time.Time{}, false},
+		{"99999999999999999999", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := chromiumTime(tt.v)
+		if !got.Equal(tt.want) || ok != tt.wantOK {
+			t.Errorf("chromiumTime(%q) = %v, %v; want %v, %v", tt.v, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// TestFixtureInstallTime checks the fixture's install_time end to end
+func TestFixtureInstallTime(t *testing.T) {
+	want := time.Date(2022, 7, 25, 13, 20, 0, 0, time.UTC)
+	bi := newFixtureInventory(t, fixtureHome)
+	for _, ext := range scanFixture(t, bi, "chrome") {
+		if ext.ID != "aaaabbbbccccddddeeeeffffgggghhhh" || ext.Profile != "Person 1" {
+			continue
+		}
+		if ext.InstalledAt == nil || !ext.InstalledAt.Equal(want) {
+			t.Errorf("InstalledAt = %v, want %v", ext.InstalledAt, want)
+		}
+		return
+	}
+	t.Error("fixture extension aaaabbbbccccddddeeeeffffgggghhhh not found")
+}
+
+func TestChromiumExtensionStatus(t *testing.T) {
+	disabled := chromiumExtensionStateDisabled
+	enabled := 1
+	tests := []struct {
+		name        string
+		settings    chromiumExtensionSettings
+		wantEnabled bool
+		wantReason  string
+	}{
+		{name: "enabled", settings: chromiumExtensionSettings{State: &enabled}, wantEnabled: true},
+		{name: "no state", wantEnabled: true},
+		{name: "zero mask", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`0`)}, wantEnabled: true},
+		{name: "disabled state alone", settings: chromiumExtensionSettings{State: &disabled}, wantReason: DisabledReasonUser},
+		{name: "user action", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`1`)}, wantReason: DisabledReasonUser},
+		{name: "permissions increase", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`4`)}, wantReason: DisabledReasonOther},
+		{name: "not verified", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`256`)}, wantReason: DisabledReasonOther},
+		{name: "greylist", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`512`)}, wantReason: DisabledReasonBlocklist},
+		{name: "corrupted", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`1024`)}, wantReason: DisabledReasonCorrupt},
+		{name: "external extension", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`8192`)}, wantReason: DisabledReasonOther},
+		{name: "update required by policy", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`16384`)}, wantReason: DisabledReasonPolicy},
+		{name: "custodian approval required", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`32768`)}, wantReason: DisabledReasonOther},
+		{name: "blocked by policy", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`65536`)}, wantReason: DisabledReasonPolicy},
+		{name: "policy over user", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`65537`)}, wantReason: DisabledReasonPolicy},
+		{name: "corrupted over user", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`1025`)}, wantReason: DisabledReasonCorrupt},
+		{name: "list user action", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`[1]`)}, wantReason: DisabledReasonUser},
+		{name: "list corrupted", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`[1024]`)}, wantReason: DisabledReasonCorrupt},
+		{name: "list blocked by policy and user", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`[1, 65536]`)}, wantReason: DisabledReasonPolicy},
+		{name: "empty list", settings: chromiumExtensionSettings{DisableReasons: json.RawMessage(`[]`)}, wantEnabled: true},
+		{name: "blacklist flag", settings: chromiumExtensionSettings{Blacklist: true}, wantReason: DisabledReasonBlocklist},
+		{name: "blacklist_state malware", settings: chromiumExtensionSettings{BlacklistState: 1}, wantReason: DisabledReasonBlocklist},
+		{name: "blacklist_state with user action", settings: chromiumExtensionSettings{BlacklistState: 3, DisableReasons: json.RawMessage(`1`)}, wantReason: DisabledReasonBlocklist},
+		{name: "policy over blacklist_state", settings: chromiumExtensionSettings{BlacklistState: 1, DisableReasons: json.RawMessage(`65536`)}, wantReason: DisabledReasonPolicy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotEnabled, gotReason := tt.settings.status()
+			if gotEnabled != tt.wantEnabled || gotReason != tt.wantReason {
+				t.Errorf("status() = %v, %q; want %v, %q", gotEnabled, gotReason, tt.wantEnabled, tt.wantReason)
+			}
+		})
+	}
+}
+
+// memNameCache keeps resolved names in memory, counting saves
+type memNameCache struct {
+	names map[string][2]string
+	saves int
+}
+
+func (c *memNameCache) LookupNames(id, version string) (string, string, bool, error) {
+	n, ok := c.names[id+"@"+version]
+	return n[0], n[1], ok, nil
+}
+
+func (c *memNameCache) SaveNames(id, version, name, shortName string) error {
+	c.names[id+"@"+version] = [2]string{name, shortName}
+	c.saves++
+	return nil
+}
+
+// TestNameCacheUnpacked edits an unpacked extension's locale between scans
+// without changing its version: the new name is reported, while a packed
+// extension keeps its cached name
+func TestNameCacheUnpacked(t *testing.T) {
+	const (
+		unpackedID = "uuuuuuuuuuuuuuuuuuuuuuuuuuuuuuuu"
+		packedID   = "pppppppppppppppppppppppppppppppp"
+	)
+	home := t.TempDir()
+	profile := filepath.Join(home, ".config", "google-chrome", "Default")
+	unpackedDir := filepath.Join(home, "src", "my-extension")
+	packedDir := filepath.Join(profile, "Extensions", packedID, "1.0_0")
+	manifest := `{"manifest_version": 3, "name": "__MSG_appName__", "version": "1.0", "default_locale": "en"}`
+	writeFile(t, filepath.Join(unpackedDir, "manifest.json"), manifest)
+	writeFile(t, filepath.Join(packedDir, "manifest.json"), manifest)
+	setName := func(dir, name string) {
+		writeFile(t, filepath.Join(dir, "_locales", "en", "messages.json"), `{"appName": {"message": "`+name+`"}}`)
+	}
+	setName(unpackedDir, "Draft")
+	setName(packedDir, "Packed")
+	prefs, err := json.Marshal(map[string]any{"extensions": map[string]any{"settings": map[string]any{
+		unpackedID: map[string]any{"location": chromiumLocationUnpacked, "path": unpackedDir},
+		packedID:   map[string]any{"location": 1, "path": packedID + "/1.0_0"},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(profile, "Preferences"), string(prefs))
+
+	cache := &memNameCache{names: make(map[string][2]string)}
+	names := func() map[string]string {
+		bi := newFixtureInventory(t, home)
+		bi.Options.NameCache = cache
+		got := make(map[string]string)
+		for _, ext := range scanFixture(t, bi, "chrome") {
+			got[ext.ID] = ext.Name
+		}
+		return got
+	}
+
+	if got := names(); got[unpackedID] != "Draft" || got[packedID] != "Packed" {
+		t.Fatalf("first scan names = %v", got)
+	}
+	setName(unpackedDir, "Renamed")
+	setName(packedDir, "Changed Without A Version Bump")
+	if got := names(); got[unpackedID] != "Renamed" || got[packedID] != "Packed" {
+		t.Errorf("second scan names = %v, want the unpacked extension renamed and the packed one cached", got)
+	}
+	if _, ok := cache.names[unpackedID+"@1.0"]; ok {
+		t.Error("unpacked extension's names were cached")
+	}
+	if cache.saves != 1 {
+		t.Errorf("%d names saved, want 1 (the packed extension, once)", cache.saves)
+	}
+}
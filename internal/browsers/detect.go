@@ -0,0 +1,71 @@
+package browsers
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// DetectBrowsers reports, for each selected browser, whether it is installed
+// and whether its profile data exists
+func (bi *BrowserInventory) DetectBrowsers(selectedBrowser string) []BrowserStatus {
+	homeDir, _ := bi.homeDir()
+
+	var statuses []BrowserStatus
+	for _, config := range bi.configs {
+		if selectedBrowser != "" && !strings.EqualFold(config.Name, selectedBrowser) {
+			continue
+		}
+
+		status := BrowserStatus{Browser: config.Name}
+		status.InstallPath = findInstall(config, homeDir)
+		status.Installed = status.InstallPath != ""
+
+		if basePath, err := bi.basePath(config, homeDir); err == nil {
+			profileRoot := basePath
+			if !config.IsFirefox {
+				profileRoot = filepath.Dir(basePath)
+			}
+			_, err := os.Stat(profileRoot)
+			status.HasProfile = err == nil
+		}
+		// Profile data implies an install even if the executable wasn't found
+		// in a standard location
+		if status.HasProfile {
+			status.Installed = true
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// findInstall returns the first existing install path for the current OS
+func findInstall(config BrowserConfig, homeDir string) string {
+	var candidates []string
+	switch runtime.GOOS {
+	case "windows":
+		candidates = config.WindowsInstallPaths
+	case "darwin":
+		candidates = config.MacOSInstallPaths
+	case "linux":
+		candidates = config.LinuxInstallPaths
+	}
+
+	for _, candidate := range candidates {
+		path := os.ExpandEnv(candidate)
+		if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			if homeDir == "" {
+				continue
+			}
+			path = filepath.Join(homeDir, rest)
+		}
+		if !filepath.IsAbs(path) {
+			continue // An unset environment variable left a relative path
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
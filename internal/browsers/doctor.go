@@ -0,0 +1,107 @@
+package browsers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Diagnose checks that the home directory resolves and that each selected
+// browser's data directory and key files (Local State, profiles.ini) can be
+// read. It only opens and reads, never writes. A browser whose data
+// directory doesn't exist is reported as skipped rather than failed, since
+// not every browser is installed.
+func (bi *BrowserInventory) Diagnose(browserList []string) []DoctorCheck {
+	var checks []DoctorCheck
+	homeDir, err := bi.homeDir()
+	if err != nil {
+		checks = append(checks, DoctorCheck{Label: "Home directory", Status: CheckFail, Detail: err.Error()})
+	} else {
+		home := dirCheck("", "Home directory", homeDir)
+		if home.Status == CheckSkip {
+			home.Status = CheckFail // Every browser path hangs off it
+		}
+		checks = append(checks, home)
+	}
+
+	for _, name := range browserList {
+		config, ok := bi.Config(name)
+		if !ok {
+			continue
+		}
+		basePath, err := bi.basePath(config, homeDir)
+		if errors.Is(err, errUnsupportedOS) {
+			checks = append(checks, DoctorCheck{Browser: config.Name, Label: "Data directory", Status: CheckSkip, Detail: "unsupported on " + runtime.GOOS})
+			continue
+		}
+		if err != nil {
+			checks = append(checks, DoctorCheck{Browser: config.Name, Label: "Data directory", Status: CheckFail, Detail: err.Error()})
+			continue
+		}
+
+		if config.IsFirefox {
+			dir := dirCheck(config.Name, "Profiles directory", basePath)
+			checks = append(checks, dir)
+			if dir.Status != CheckPass {
+				continue
+			}
+			checks = append(checks, fileCheck(config.Name, "profiles.ini", filepath.Join(basePath, "profiles.ini")))
+			continue
+		}
+
+		userDataDir := filepath.Dir(basePath)
+		dir := dirCheck(config.Name, "User Data directory", userDataDir)
+		checks = append(checks, dir)
+		if dir.Status != CheckPass {
+			continue
+		}
+		checks = append(checks,
+			fileCheck(config.Name, "Local State", filepath.Join(userDataDir, "Local State")),
+			dirCheck(config.Name, "Default profile", basePath),
+		)
+	}
+	return checks
+}
+
+// dirCheck reports whether path is a directory that can be listed. A
+// missing directory is skipped, anything else that stops the listing fails.
+func dirCheck(browser, label, path string) DoctorCheck {
+	check := DoctorCheck{Browser: browser, Label: label, Path: path, Status: CheckPass}
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		check.Status, check.Detail = CheckSkip, "not found"
+	case err != nil:
+		check.Status, check.Detail = CheckFail, err.Error()
+	case !info.IsDir():
+		check.Status, check.Detail = CheckFail, "not a directory"
+	default:
+		if _, err := os.ReadDir(path); err != nil {
+			check.Status, check.Detail = CheckFail, err.Error()
+		}
+	}
+	return check
+}
+
+// fileCheck reports whether path is a file that can be opened and read. A
+// missing file is skipped, since a fresh install may not have written it yet.
+func fileCheck(browser, label, path string) DoctorCheck {
+	check := DoctorCheck{Browser: browser, Label: label, Path: path, Status: CheckPass}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		check.Status, check.Detail = CheckSkip, "not found"
+		return check
+	}
+	if err != nil {
+		check.Status, check.Detail = CheckFail, err.Error()
+		return check
+	}
+	defer f.Close()
+	if _, err := f.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) {
+		check.Status, check.Detail = CheckFail, fmt.Sprintf("unreadable: %v", err)
+	}
+	return check
+}
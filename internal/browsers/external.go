@@ -0,0 +1,146 @@
+package browsers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// externalExtension is one preinstall declaration from an External Extensions
+// JSON file
+type externalExtension struct {
+	UpdateURL string `json:"external_update_url"`
+	CRX       string `json:"external_crx"`
+	Version   string `json:"external_version"`
+}
+
+// externalPathsFor returns the External Extensions directories to read for
+// the current OS
+func externalPathsFor(config BrowserConfig, basePath, homeDir string) []string {
+	var candidates []string
+	switch runtime.GOOS {
+	case "windows":
+		candidates = config.WindowsExternalPaths
+	case "darwin":
+		candidates = config.MacOSExternalPaths
+	case "linux":
+		candidates = config.LinuxExternalPaths
+	}
+
+	dirs := []string{filepath.Join(filepath.Dir(basePath), "External Extensions")}
+	for _, candidate := range candidates {
+		path := os.ExpandEnv(candidate)
+		if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			if homeDir == "" {
+				continue
+			}
+			path = filepath.Join(homeDir, rest)
+		}
+		if filepath.IsAbs(path) {
+			dirs = append(dirs, path)
+		}
+	}
+	return dirs
+}
+
+// loadExternalExtensions reads the per-extension <id>.json files and any
+// external_extensions.json in the External Extensions directories. Entries are
+// returned as extensions with InstallSource set to external and Path pointing
+// at the declaring file.
+func (bi *BrowserInventory) loadExternalExtensions(basePath string, config BrowserConfig, homeDir string, debug bool) []Extension {
+	var declared []Extension
+	seen := make(map[string]bool)
+	add := func(id, path string, ext externalExtension) {
+		if !isChromiumExtensionID(id) || seen[id] {
+			return
+		}
+		seen[id] = true
+		declared = append(declared, Extension{
+			ID:            id,
+			Type:          TypeExtension,
+			Version:       ext.Version,
+			Browser:       config.Name,
+			Path:          path,
+			InstallSource: InstallSourceExternal,
+			UpdateURL:     ext.UpdateURL,
+		})
+	}
+
+	for _, dir := range externalPathsFor(config, basePath, homeDir) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) && debug {
+				fmt.Printf("Warning: Failed to read %s: %v\n", dir, err)
+			}
+			continue
+		}
+		if debug {
+			fmt.Printf("Checking external extensions in %s\n", dir)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || filepath.Ext(name) != ".json" {
+				continue
+			}
+			path := filepath.Join(dir, name)
+			if name == "external_extensions.json" {
+				var all map[string]externalExtension
+				if err := readJSONFile(path, &all); err != nil {
+					if debug {
+						fmt.Printf("Warning: Failed to read %s: %v\n", path, err)
+					}
+					bi.recordFileError(path, err)
+					continue
+				}
+				for id, ext := range all {
+					add(id, path, ext)
+				}
+				continue
+			}
+			var ext externalExtension
+			if err := readJSONFile(path, &ext); err != nil {
+				if debug {
+					fmt.Printf("Warning: Failed to read %s: %v\n", path, err)
+				}
+				bi.recordFileError(path, err)
+				continue
+			}
+			add(strings.TrimSuffix(name, ".json"), path, ext)
+		}
+	}
+	return declared
+}
+
+// mergeExternalExtensions marks on-disk extensions that were declared
+// externally and appends declarations that aren't installed in any profile
+// yet. Those have no profile and are reported disabled.
+func mergeExternalExtensions(extensions, declared []Extension) []Extension {
+	if len(declared) == 0 {
+		return extensions
+	}
+	byID := make(map[string]Extension, len(declared))
+	for _, d := range declared {
+		byID[d.ID] = d
+	}
+
+	installed := make(map[string]bool)
+	for i := range extensions {
+		d, ok := byID[extensions[i].ID]
+		if !ok {
+			continue
+		}
+		installed[d.ID] = true
+		extensions[i].InstallSource = InstallSourceExternal
+		if extensions[i].UpdateURL == "" {
+			extensions[i].UpdateURL = d.UpdateURL
+		}
+	}
+	for _, d := range declared {
+		if !installed[d.ID] {
+			extensions = append(extensions, d)
+		}
+	}
+	return extensions
+}
@@ -2,107 +2,363 @@ package browsers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
+// firefoxPrivateBrowsingPermission is the extension-preferences.json
+// permission recording that an add-on may run in private windows
+const firefoxPrivateBrowsingPermission = "internal:privateBrowsingAllowed"
+
+// firefoxBlocklistNotBlocked is the blocklistState of an add-on that isn't blocked
+const firefoxBlocklistNotBlocked = 0
+
+// firefoxInstallSourcePolicy is the installTelemetryInfo source of add-ons
+// installed through enterprise policies
+const firefoxInstallSourcePolicy = "enterprise-policy"
+
 // getFirefoxExtensions handles Firefox extensions
 func (bi *BrowserInventory) getFirefoxExtensions(basePath string, config BrowserConfig, debug bool) ([]Extension, error) {
 	if _, err := os.Stat(basePath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("profiles directory not found at %s", basePath)
 	}
 
+	profiles, err := readFirefoxProfiles(basePath)
+	if err != nil {
+		// Listed as skipped, but with nothing readable the browser has failed
+		bi.skipPermissionDenied(err, debug)
+		return nil, err
+	}
+	if debug && !fileExists(filepath.Join(basePath, "profiles.ini")) {
+		fmt.Printf("Note: No profiles.ini in %s, found %d *.default* profile directories\n", basePath, len(profiles))
+	}
+
+	var jobs []profileJob
+	for _, profile := range profiles {
+		if debug {
+			fmt.Printf("Found profile: %s (default: %v)\n", profile.Path, profile.Default)
+		}
+		profilePath := profile.Path
+		jobs = append(jobs, profileJob{
+			key: profilePath,
+			scan: func() ([]Extension, error) {
+				return bi.scanFirefoxProfile(profilePath, config, debug)
+			},
+		})
+	}
+
+	allExtensions, err := bi.runProfileScans(config.Name, jobs, debug)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(allExtensions) == 0 && debug {
+		fmt.Printf("Note: No extensions found across all profiles in %s\n", basePath)
+	}
+
+	return allExtensions, nil
+}
+
+// readFirefoxProfiles parses profiles.ini in basePath. Relative profile paths
+// are resolved against basePath. Without a profiles.ini, the profile
+// directories are found by name instead.
+func readFirefoxProfiles(basePath string) ([]Profile, error) {
 	profilesIni := filepath.Join(basePath, "profiles.ini")
 	iniData, err := os.ReadFile(profilesIni)
+	if os.IsNotExist(err) {
+		return findFirefoxProfiles(basePath), nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read profiles.ini at %s: %v", profilesIni, err)
+		return nil, fmt.Errorf("failed to read profiles.ini at %s: %w", profilesIni, err)
 	}
 
-	lines := strings.Split(string(iniData), "\n")
-	var profiles []string
-	var currentSection string
-	var defaultProfilePath string
-
-	// Collect all profiles and find default
-	for _, line := range lines {
+	var profiles []Profile
+	var current *Profile
+	flush := func() {
+		if current != nil && current.Path != "" {
+			profiles = append(profiles, *current)
+		}
+		current = nil
+	}
+	for _, line := range strings.Split(string(decodeText(iniData)), "\n") {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			currentSection = line
+			flush()
+			current = &Profile{Browser: "Firefox"}
 			continue
 		}
-		if strings.HasPrefix(line, "Path=") && currentSection != "" {
-			profile := strings.TrimPrefix(line, "Path=")
-			profiles = append(profiles, profile)
-			if debug {
-				fmt.Printf("Found profile in profiles.ini: %s\n", profile)
+		if current == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Name":
+			current.Name = value
+		case "Path":
+			current.Dir = value
+			current.Path = value
+			if !filepath.IsAbs(value) {
+				current.Path = filepath.Join(basePath, value)
 			}
+		case "Default":
+			current.Default = value == "1"
+		}
+	}
+	flush()
+	return profiles, nil
+}
+
+// findFirefoxProfiles lists the *.default* directories in basePath and its
+// Profiles subdirectory (where Windows keeps them), for installs whose
+// profiles.ini is missing. Those in basePath come first, each directory's in
+// name order; the result may be empty.
+func findFirefoxProfiles(basePath string) []Profile {
+	var profiles []Profile
+	for _, dir := range []string{basePath, filepath.Join(basePath, "Profiles")} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
 		}
-		if strings.HasPrefix(line, "Default=1") && currentSection != "" {
-			for _, prevLine := range lines {
-				if strings.HasPrefix(prevLine, "Path=") {
-					defaultProfilePath = strings.TrimPrefix(prevLine, "Path=")
-					if debug {
-						fmt.Printf("Found default profile in profiles.ini: %s\n", defaultProfilePath)
-					}
-					break
-				}
+		for _, entry := range entries {
+			if !isDirEntry(dir, entry) || !strings.Contains(entry.Name(), ".default") {
+				continue
 			}
+			rel, err := filepath.Rel(basePath, filepath.Join(dir, entry.Name()))
+			if err != nil {
+				rel = entry.Name()
+			}
+			profiles = append(profiles, Profile{
+				Browser: "Firefox",
+				Dir:     filepath.ToSlash(rel),
+				Path:    filepath.Join(dir, entry.Name()),
+			})
 		}
 	}
+	return profiles
+}
 
-	var allExtensions []Extension
-	for _, profilePath := range profiles {
-		if !filepath.IsAbs(profilePath) {
-			profilePath = filepath.Join(basePath, profilePath)
+// scanFirefoxProfile reads the add-ons installed in one Firefox profile
+func (bi *BrowserInventory) scanFirefoxProfile(profilePath string, config BrowserConfig, debug bool) ([]Extension, error) {
+	if debug {
+		fmt.Printf("Checking profile: %s\n", profilePath)
+	}
+	if bi.skipUnreadableDir(profilePath, debug) {
+		return nil, nil
+	}
+
+	extensionsJSON := filepath.Join(profilePath, "extensions.json")
+	var extData struct {
+		Addons []struct {
+			ID             string `json:"id"`
+			Type           string `json:"type"`
+			Version        string `json:"version"`
+			Active         bool   `json:"active"`
+			UserDisabled   bool   `json:"userDisabled"`
+			AppDisabled    bool   `json:"appDisabled"`
+			BlocklistState int    `json:"blocklistState"`
+			Path           string `json:"path"`
+			ForeignInstall bool   `json:"foreignInstall"`
+			InstallInfo    struct {
+				Source string `json:"source"`
+			} `json:"installTelemetryInfo"`
+			UserPerms struct {
+				Permissions []string `json:"permissions"`
+				Origins     []string `json:"origins"`
+			} `json:"userPermissions"`
+			DefaultLocale struct {
+				Name string `json:"name"`
+			} `json:"defaultLocale"`
+		} `json:"addons"`
+	}
+	if err := readJSONFile(extensionsJSON, &extData); err != nil {
+		if os.IsNotExist(err) {
+			if debug {
+				fmt.Printf("Note: extensions.json not found at %s, skipping profile\n", extensionsJSON)
+			}
+			return nil, nil
+		}
+		if bi.skipPermissionDenied(err, debug) {
+			return nil, nil
 		}
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			return nil, fmt.Errorf("failed to read extensions.json at %s: %v", extensionsJSON, err)
+		}
+		// A half-written file from a running browser shouldn't sink the other profiles
 		if debug {
-			fmt.Printf("Checking profile: %s\n", profilePath)
+			fmt.Printf("Warning: Skipping profile, %v\n", err)
 		}
+		bi.recordFileError(extensionsJSON, err)
+		return nil, nil
+	}
 
-		extensionsJSON := filepath.Join(profilePath, "extensions.json")
-		data, err := os.ReadFile(extensionsJSON)
-		if err != nil {
-			if os.IsNotExist(err) {
-				if debug {
-					fmt.Printf("Note: extensions.json not found at %s, skipping profile\n", extensionsJSON)
-				}
-				continue
+	var rawAddons map[string]json.RawMessage
+	if bi.Options.RawManifest {
+		rawAddons = bi.loadFirefoxRawAddons(extensionsJSON, debug)
+	}
+	metadata := bi.loadFirefoxAddonMetadata(profilePath, debug)
+	grants := bi.loadFirefoxExtensionPreferences(profilePath, debug)
+
+	var allExtensions []Extension
+	for _, addon := range extData.Addons {
+		profileName := filepath.Base(profilePath) // Extract profile name
+		var disabledReason string
+		if !addon.Active {
+			switch {
+			case addon.BlocklistState != firefoxBlocklistNotBlocked:
+				disabledReason = DisabledReasonBlocklist
+			case addon.UserDisabled:
+				disabledReason = DisabledReasonUser
+			case addon.AppDisabled:
+				disabledReason = DisabledReasonPolicy
+			default:
+				disabledReason = DisabledReasonOther
 			}
-			return nil, fmt.Errorf("failed to read extensions.json at %s: %v", extensionsJSON, err)
 		}
+		addonType := addon.Type
+		if addonType == "" {
+			addonType = TypeExtension
+		}
+		var installedBy string
+		switch {
+		case addon.InstallInfo.Source == firefoxInstallSourcePolicy:
+			installedBy = InstalledByPolicy
+		case addon.ForeignInstall:
+			installedBy = InstalledByExternal
+		}
+		ext := Extension{
+			Type:            addonType,
+			Name:            addon.DefaultLocale.Name,
+			Version:         addon.Version,
+			ID:              addon.ID,
+			Enabled:         addon.Active,
+			DisabledReason:  disabledReason,
+			Browser:         config.Name,
+			Profile:         profileName,
+			Permissions:     addon.UserPerms.Permissions,
+			HostPermissions: addon.UserPerms.Origins,
+			Path:            addon.Path,
+			InstalledBy:     installedBy,
+		}
+		// addons.json only adds listing metadata; extensions.json stays
+		// authoritative for state
+		if meta, ok := metadata[addon.ID]; ok {
+			ext.Author = meta.Creator.Name
+			ext.Homepage = meta.HomepageURL
+			ext.Rating = meta.AverageRating
+		}
+		if raw, ok := rawAddons[addon.ID]; ok {
+			ext.RawManifest = raw
+		}
+		if grant, ok := grants[addon.ID]; ok {
+			ext.GrantedPermissions = grant.Permissions
+			ext.GrantedHostPermissions = grant.Origins
+			ext.IncognitoAllowed = slices.Contains(grant.Permissions, firefoxPrivateBrowsingPermission)
+		}
+		allExtensions = append(allExtensions, ext)
+	}
 
-		var extData struct {
-			Addons []struct {
-				ID            string `json:"id"`
-				Version       string `json:"version"`
-				Active        bool   `json:"active"`
-				DefaultLocale struct {
-					Name string `json:"name"`
-				} `json:"defaultLocale"`
-			} `json:"addons"`
-		}
-		if err := json.Unmarshal(data, &extData); err != nil {
-			return nil, fmt.Errorf("failed to parse extensions.json at %s: %v", extensionsJSON, err)
-		}
-
-		for _, addon := range extData.Addons {
-			profileName := filepath.Base(profilePath) // Extract profile name
-			allExtensions = append(allExtensions, Extension{
-				Name:    addon.DefaultLocale.Name,
-				Version: addon.Version,
-				ID:      addon.ID,
-				Enabled: addon.Active,
-				Browser: config.Name,
-				Profile: profileName,
-			})
+	markProfileDuplicates(allExtensions, debug)
+	return allExtensions, nil
+}
+
+// loadFirefoxRawAddons returns each add-on's extensions.json object keyed by
+// ID, for Options.RawManifest
+func (bi *BrowserInventory) loadFirefoxRawAddons(extensionsJSON string, debug bool) map[string]json.RawMessage {
+	var extData struct {
+		Addons []json.RawMessage `json:"addons"`
+	}
+	if err := readJSONFile(extensionsJSON, &extData); err != nil {
+		if debug {
+			fmt.Printf("Warning: Failed to re-read %s for raw add-on data: %v\n", extensionsJSON, err)
+		}
+		return nil
+	}
+	rawAddons := make(map[string]json.RawMessage, len(extData.Addons))
+	for _, raw := range extData.Addons {
+		var addon struct {
+			ID string `json:"id"`
+		}
+		if json.Unmarshal(raw, &addon) == nil && addon.ID != "" {
+			rawAddons[addon.ID] = rawJSON(raw)
 		}
 	}
+	return rawAddons
+}
 
-	if len(allExtensions) == 0 && debug {
-		fmt.Printf("Note: No extensions found across all profiles in %s\n", basePath)
+// firefoxAddonMetadata is an entry in addons.json, the add-on repository cache
+type firefoxAddonMetadata struct {
+	ID      string `json:"id"`
+	Creator struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"creator"`
+	HomepageURL   string  `json:"homepageURL"`
+	AverageRating float64 `json:"averageRating"`
+}
+
+// loadFirefoxAddonMetadata reads addons.json keyed by add-on ID. The file is
+// absent on fresh profiles, in which case an empty map is returned.
+func (bi *BrowserInventory) loadFirefoxAddonMetadata(profilePath string, debug bool) map[string]firefoxAddonMetadata {
+	metadata := make(map[string]firefoxAddonMetadata)
+	addonsJSON := filepath.Join(profilePath, "addons.json")
+
+	var data struct {
+		Addons []firefoxAddonMetadata `json:"addons"`
+	}
+	if err := readJSONFile(addonsJSON, &data); err != nil {
+		if os.IsNotExist(err) {
+			if debug {
+				fmt.Printf("Note: addons.json not found at %s\n", addonsJSON)
+			}
+		} else {
+			if debug {
+				fmt.Printf("Warning: Failed to read addons.json: %v\n", err)
+			}
+			bi.recordFileError(addonsJSON, err)
+		}
+		return metadata
 	}
 
-	return allExtensions, nil
+	for _, addon := range data.Addons {
+		metadata[addon.ID] = addon
+	}
+	return metadata
+}
+
+// firefoxExtensionPreferences is an add-on's entry in extension-preferences.json:
+// the optional permissions and origins the user granted at runtime, plus
+// internal grants such as internal:privateBrowsingAllowed
+type firefoxExtensionPreferences struct {
+	Permissions []string `json:"permissions"`
+	Origins     []string `json:"origins"`
+}
+
+// loadFirefoxExtensionPreferences reads extension-preferences.json keyed by
+// add-on ID. The file only exists once an add-on has been granted something,
+// so a missing file yields an empty map.
+func (bi *BrowserInventory) loadFirefoxExtensionPreferences(profilePath string, debug bool) map[string]firefoxExtensionPreferences {
+	prefs := make(map[string]firefoxExtensionPreferences)
+	prefsJSON := filepath.Join(profilePath, "extension-preferences.json")
+	if err := readJSONFile(prefsJSON, &prefs); err != nil {
+		if os.IsNotExist(err) {
+			if debug {
+				fmt.Printf("Note: extension-preferences.json not found at %s\n", prefsJSON)
+			}
+		} else {
+			if debug {
+				fmt.Printf("Warning: Failed to read extension-preferences.json: %v\n", err)
+			}
+			bi.recordFileError(prefsJSON, err)
+		}
+		return make(map[string]firefoxExtensionPreferences)
+	}
+	return prefs
 }
@@ -0,0 +1,64 @@
+package browsers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestFindFirefoxProfiles lays out a Firefox directory with no profiles.ini
+// and checks which directories are taken for profiles
+func TestFindFirefoxProfiles(t *testing.T) {
+	base := t.TempDir()
+	for _, dir := range []string{
+		"wxyz9876.default-release",
+		"abcd1234.default",
+		"Crash Reports",
+		"Pending Pings",
+		"Profiles/efgh5678.default-esr",
+		"Profiles/notaprofile",
+	} {
+		if err := os.MkdirAll(filepath.Join(base, filepath.FromSlash(dir)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A file with a profile-like name isn't a profile
+	writeFile(t, filepath.Join(base, "stale.default"), "")
+
+	profiles, err := readFirefoxProfiles(base)
+	if err != nil {
+		t.Fatalf("readFirefoxProfiles: %v", err)
+	}
+	want := []Profile{
+		{Browser: "Firefox", Dir: "abcd1234.default", Path: filepath.Join(base, "abcd1234.default")},
+		{Browser: "Firefox", Dir: "wxyz9876.default-release", Path: filepath.Join(base, "wxyz9876.default-release")},
+		{Browser: "Firefox", Dir: "Profiles/efgh5678.default-esr", Path: filepath.Join(base, "Profiles", "efgh5678.default-esr")},
+	}
+	if !reflect.DeepEqual(profiles, want) {
+		t.Errorf("readFirefoxProfiles() =\n%+v\nwant\n%+v", profiles, want)
+	}
+}
+
+func TestFindFirefoxProfilesEmpty(t *testing.T) {
+	base := t.TempDir()
+	if err := os.Mkdir(filepath.Join(base, "Crash Reports"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := readFirefoxProfiles(base)
+	if err != nil || len(profiles) != 0 {
+		t.Errorf("readFirefoxProfiles() = %+v, %v; want no profiles and no error", profiles, err)
+	}
+}
+
+// TestNoProfilesIniFixture scans testdata/home-no-profiles-ini end to end
+func TestNoProfilesIniFixture(t *testing.T) {
+	bi := newFixtureInventory(t, "testdata/home-no-profiles-ini")
+	var got []fixtureRow
+	for _, ext := range scanFixture(t, bi, "firefox") {
+		got = append(got, rowOf(ext))
+	}
+	if len(got) != 1 || got[0].Profile != "wxyz9876.default-release" || got[0].ID != "noini@example.com" || got[0].Name != "No profiles.ini Add-on" {
+		t.Errorf("scan of home-no-profiles-ini =\n%s", formatRows(got))
+	}
+}
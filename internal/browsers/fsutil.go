@@ -0,0 +1,30 @@
+package browsers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// resolveDir evaluates symlinks in path so directory reads and reported paths
+// refer to the real location, falling back to path if it can't be resolved
+func resolveDir(path string, debug bool) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+	if debug && resolved != path {
+		fmt.Printf("Debug: Resolved symlink %s -> %s\n", path, resolved)
+	}
+	return resolved
+}
+
+// isDirEntry reports whether entry is a directory, following symlinks, which
+// os.DirEntry.IsDir does not
+func isDirEntry(parent string, entry os.DirEntry) bool {
+	if entry.Type()&os.ModeSymlink == 0 {
+		return entry.IsDir()
+	}
+	info, err := os.Stat(filepath.Join(parent, entry.Name()))
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,50 @@
+package browsers
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// TestSymlinkedProfiles scans a User Data directory whose Default profile is
+// a symlink to another volume and whose Profile 1 has a symlinked extension
+// directory
+func TestSymlinkedProfiles(t *testing.T) {
+	home := t.TempDir()
+	elsewhere := t.TempDir()
+	userData := filepath.Join(home, ".config", "google-chrome")
+
+	const linkedProfileID = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const linkedExtensionID = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	realProfile := filepath.Join(elsewhere, "encrypted", "Default")
+	writeChromiumExtension(t, realProfile, linkedProfileID, "In Symlinked Profile")
+	if err := os.MkdirAll(userData, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(realProfile, filepath.Join(userData, "Default")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	realExtension := filepath.Join(elsewhere, "shared")
+	writeChromiumExtension(t, realExtension, linkedExtensionID, "Symlinked Extension")
+	profile1Extensions := filepath.Join(userData, "Profile 1", "Extensions")
+	if err := os.MkdirAll(profile1Extensions, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(realExtension, "Extensions", linkedExtensionID), filepath.Join(profile1Extensions, linkedExtensionID)); err != nil {
+		t.Fatal(err)
+	}
+
+	bi := newFixtureInventory(t, home)
+	exts := scanFixture(t, bi, "Chrome")
+	var got []string
+	for _, e := range exts {
+		got = append(got, e.Profile+"/"+e.ID)
+	}
+	sort.Strings(got)
+	want := []string{"Default/" + linkedProfileID, "Profile 1/" + linkedExtensionID}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("found %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,376 @@
+package browsers
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fingerprint returns a deterministic SHA-256 over the (browser, id, version,
+// enabled) tuples of the extensions. Tuples are JSON-encoded and sorted so the
+// result is independent of scan order.
+func Fingerprint(extensions []Extension) string {
+	lines := make([]string, 0, len(extensions))
+	for _, ext := range extensions {
+		tuple, _ := json.Marshal([]string{ext.Browser, ext.ID, ext.Version, strconv.FormatBool(ext.Enabled)})
+		lines = append(lines, string(tuple))
+	}
+	sort.Strings(lines)
+
+	h := sha256.New()
+	for _, line := range lines {
+		h.Write([]byte(line))
+		h.Write([]byte("\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// FilterByID returns the extensions with the given ID (case-insensitive)
+func FilterByID(extensions []Extension, id string) []Extension {
+	var matches []Extension
+	for _, ext := range extensions {
+		if strings.EqualFold(ext.ID, id) {
+			matches = append(matches, ext)
+		}
+	}
+	return matches
+}
+
+// Install locates one copy of an extension
+type Install struct {
+	Browser string `json:"browser" toml:"browser"`
+	Profile string `json:"profile,omitempty" toml:"profile,omitempty"`
+	Version string `json:"version" toml:"version"`
+	Path    string `json:"path,omitempty" toml:"path,omitempty"`
+	// ComputedID is the ID the copy's manifest key yields, with
+	// Options.VerifyIDs
+	ComputedID string `json:"computed_id,omitempty" toml:"computed_id,omitempty"`
+}
+
+// DuplicateGroup is an extension ID installed in more than one browser or profile
+type DuplicateGroup struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Installs []Install `json:"installs"`
+}
+
+// FindDuplicates groups extensions by ID and returns the IDs installed in more
+// than one browser/profile, sorted by ID. Multiple versions within the same
+// profile don't count as duplicates.
+func FindDuplicates(extensions []Extension) []DuplicateGroup {
+	groups := make(map[string]*DuplicateGroup)
+	locations := make(map[string]map[string]bool)
+	for _, ext := range extensions {
+		g, ok := groups[ext.ID]
+		if !ok {
+			g = &DuplicateGroup{ID: ext.ID, Name: ext.DisplayName()}
+			groups[ext.ID] = g
+			locations[ext.ID] = make(map[string]bool)
+		}
+		g.Installs = append(g.Installs, Install{
+			Browser:    ext.Browser,
+			Profile:    ext.Profile,
+			Version:    ext.Version,
+			Path:       ext.Path,
+			ComputedID: ext.ComputedID,
+		})
+		locations[ext.ID][ext.Browser+"\x00"+ext.Profile] = true
+	}
+
+	var duplicates []DuplicateGroup
+	for id, g := range groups {
+		if len(locations[id]) > 1 {
+			duplicates = append(duplicates, *g)
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].ID < duplicates[j].ID })
+	return duplicates
+}
+
+// SeverityHigh is the severity of a KeyConflict
+const SeverityHigh = "high"
+
+// KeyConflict is an extension ID whose copies carry manifest keys that yield
+// different IDs. Store installs of one extension share a key, so a conflict
+// means at least one copy claims an ID it doesn't own, a known way for
+// malware to pass as a trusted extension.
+type KeyConflict struct {
+	ID       string    `json:"id" toml:"id"`
+	Name     string    `json:"name" toml:"name"`
+	Severity string    `json:"severity" toml:"severity"`
+	Installs []Install `json:"installs" toml:"installs"`
+}
+
+// FindKeyConflicts groups extensions by ID and returns the IDs whose copies
+// have keys yielding more than one ComputedID, sorted by ID. It needs a scan
+// with Options.VerifyIDs; copies without a key don't count towards a conflict
+// but are listed with it.
+func FindKeyConflicts(extensions []Extension) []KeyConflict {
+	groups := make(map[string]*KeyConflict)
+	computed := make(map[string]map[string]bool)
+	for _, ext := range extensions {
+		g, ok := groups[ext.ID]
+		if !ok {
+			g = &KeyConflict{ID: ext.ID, Name: ext.DisplayName(), Severity: SeverityHigh}
+			groups[ext.ID] = g
+			computed[ext.ID] = make(map[string]bool)
+		}
+		g.Installs = append(g.Installs, Install{
+			Browser:    ext.Browser,
+			Profile:    ext.Profile,
+			Version:    ext.Version,
+			Path:       ext.Path,
+			ComputedID: ext.ComputedID,
+		})
+		if ext.ComputedID != "" {
+			computed[ext.ID][ext.ComputedID] = true
+		}
+	}
+
+	var conflicts []KeyConflict
+	for id, g := range groups {
+		if len(computed[id]) > 1 {
+			conflicts = append(conflicts, *g)
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].ID < conflicts[j].ID })
+	return conflicts
+}
+
+// markProfileDuplicates sets DuplicateInProfile on every extension whose ID
+// appears more than once in one profile's results: a stray version directory
+// Preferences doesn't account for, a differently cased copy, or an unpacked
+// load of an installed ID. Versions that Preferences marks as superseded are
+// already dropped, so these point at profile corruption.
+func markProfileDuplicates(extensions []Extension, debug bool) {
+	indexes := make(map[string][]int)
+	for i, ext := range extensions {
+		indexes[ext.ID] = append(indexes[ext.ID], i)
+	}
+	for id, idx := range indexes {
+		if len(idx) < 2 {
+			continue
+		}
+		var paths []string
+		for _, i := range idx {
+			extensions[i].DuplicateInProfile = true
+			paths = append(paths, extensions[i].Path)
+		}
+		if debug {
+			fmt.Printf("Warning: %s is installed %d times in profile %s: %s\n", id, len(idx), extensions[idx[0]].Profile, strings.Join(paths, ", "))
+		}
+	}
+}
+
+// Kinds of PermissionCount
+const (
+	PermissionKindAPI  = "permission"
+	PermissionKindHost = "host"
+)
+
+// PermissionCount is how many extensions request one permission or host
+// permission pattern
+type PermissionCount struct {
+	Permission string `json:"permission"`
+	Kind       string `json:"kind"`
+	// Count is installs requesting it; UniqueCount is distinct extension IDs
+	Count       int `json:"count"`
+	UniqueCount int `json:"unique_count"`
+}
+
+// SummarizePermissions tallies the permissions and host permissions requested
+// across the inventory, most requested first, then by kind and name. A
+// permission listed twice by one extension counts once.
+func SummarizePermissions(extensions []Extension) []PermissionCount {
+	counts := make(map[[2]string]*PermissionCount)
+	ids := make(map[[2]string]map[string]bool)
+	for _, ext := range extensions {
+		seen := make(map[[2]string]bool)
+		add := func(kind string, perms []string) {
+			for _, perm := range perms {
+				key := [2]string{kind, perm}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				c, ok := counts[key]
+				if !ok {
+					c = &PermissionCount{Permission: perm, Kind: kind}
+					counts[key] = c
+					ids[key] = make(map[string]bool)
+				}
+				c.Count++
+				ids[key][ext.ID] = true
+			}
+		}
+		add(PermissionKindAPI, ext.Permissions)
+		add(PermissionKindHost, ext.HostPermissions)
+	}
+
+	summary := make([]PermissionCount, 0, len(counts))
+	for key, c := range counts {
+		c.UniqueCount = len(ids[key])
+		summary = append(summary, *c)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		a, b := summary[i], summary[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Permission < b.Permission
+	})
+	return summary
+}
+
+// MergeProfiles collapses the installs of each extension in a browser into
+// one entry listing the profiles it was found in, for a view of what software
+// is present rather than how often. The entry is the install with the highest
+// version (the first one scanned on a tie), so per-install fields such as
+// Enabled and Path describe that copy; its Profile and User are cleared in
+// favor of Profiles. Entries keep the order of their first install.
+func MergeProfiles(extensions []Extension) []Extension {
+	var merged []Extension
+	index := make(map[string]int)
+	for _, ext := range extensions {
+		profile := ext.Profile
+		if ext.User != "" {
+			profile = ext.User + "/" + profile
+		}
+		key := ext.Browser + "\x00" + ext.ID
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			ext.Profiles = []string{profile}
+			ext.Profile, ext.User = "", ""
+			merged = append(merged, ext)
+			continue
+		}
+		profiles := merged[i].Profiles
+		if !slices.Contains(profiles, profile) {
+			profiles = append(profiles, profile)
+		}
+		if compareVersions(ext.Version, merged[i].Version) > 0 {
+			ext.Profile, ext.User = "", ""
+			merged[i] = ext
+		}
+		merged[i].Profiles = profiles
+	}
+	return merged
+}
+
+// compareVersions orders dotted version strings part by part, numerically
+// where both parts start with digits ("1.10" > "1.9") and then by any
+// remaining suffix ("1.0b2" < "1.0b3"); a part without a suffix sorts after
+// one with, so "1.0" > "1.0b2". Missing parts count as "0".
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		pa, pb := "0", "0"
+		if i < len(as) {
+			pa = as[i]
+		}
+		if i < len(bs) {
+			pb = bs[i]
+		}
+		na, sa := splitVersionPart(pa)
+		nb, sb := splitVersionPart(pb)
+		if c := cmp.Compare(na, nb); c != 0 {
+			return c
+		}
+		switch {
+		case sa == sb:
+			continue
+		case sa == "":
+			return 1
+		case sb == "":
+			return -1
+		}
+		return strings.Compare(sa, sb)
+	}
+	return 0
+}
+
+// splitVersionPart splits a version part into its leading number and the
+// rest, e.g. "0b2" into 0 and "b2"
+func splitVersionPart(part string) (int, string) {
+	end := 0
+	for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(part[:end])
+	return n, part[end:]
+}
+
+// ExcludeBuiltin drops browser-bundled component extensions
+func ExcludeBuiltin(extensions []Extension) []Extension {
+	var filtered []Extension
+	for _, ext := range extensions {
+		if !ext.Builtin {
+			filtered = append(filtered, ext)
+		}
+	}
+	return filtered
+}
+
+// FilterTypes returns the extensions whose Type is one of types. Extensions
+// without a Type (cached before the field existed) count as extensions.
+func FilterTypes(extensions []Extension, types []string) []Extension {
+	var matches []Extension
+	for _, ext := range extensions {
+		t := ext.Type
+		if t == "" {
+			t = TypeExtension
+		}
+		for _, want := range types {
+			if strings.EqualFold(t, want) {
+				matches = append(matches, ext)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// FilterIncognito returns the extensions allowed to run in incognito or
+// private windows
+func FilterIncognito(extensions []Extension) []Extension {
+	var matches []Extension
+	for _, ext := range extensions {
+		if ext.IncognitoAllowed {
+			matches = append(matches, ext)
+		}
+	}
+	return matches
+}
+
+// FilterNonUserInstalls returns the extensions that weren't installed by the
+// user: those with an InstalledBy value
+func FilterNonUserInstalls(extensions []Extension) []Extension {
+	var matches []Extension
+	for _, ext := range extensions {
+		if ext.InstalledBy != "" {
+			matches = append(matches, ext)
+		}
+	}
+	return matches
+}
+
+// FilterUnpacked returns the extensions loaded unpacked in developer mode
+func FilterUnpacked(extensions []Extension) []Extension {
+	var matches []Extension
+	for _, ext := range extensions {
+		if ext.InstallSource == InstallSourceUnpacked {
+			matches = append(matches, ext)
+		}
+	}
+	return matches
+}
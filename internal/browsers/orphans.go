@@ -0,0 +1,126 @@
+package browsers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// FindOrphans reports Chromium extension directories where no version folder
+// holds a parseable manifest. Firefox keeps add-ons as single .xpi files, so it
+// has no equivalent and is skipped.
+func (bi *BrowserInventory) FindOrphans(selectedBrowser string, debug bool) ([]Orphan, error) {
+	homeDir, _ := bi.homeDir()
+
+	var orphans []Orphan
+	for _, config := range bi.configs {
+		if config.IsFirefox || (selectedBrowser != "" && !strings.EqualFold(config.Name, selectedBrowser)) {
+			continue
+		}
+
+		basePath, err := bi.basePath(config, homeDir)
+		if err != nil {
+			if errors.Is(err, errUnsupportedOS) {
+				if debug {
+					fmt.Printf("Warning: Unsupported OS %s for %s\n", runtime.GOOS, config.Name)
+				}
+				continue
+			}
+			return nil, fmt.Errorf("failed to resolve %s path: %w", config.Name, err)
+		}
+		profileBase := filepath.Dir(basePath)
+		entries, err := readUserDataDir(profileBase)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read profile directory: %v", err)
+		}
+		profileNames := bi.loadChromiumProfileNames(profileBase, debug)
+
+		for _, entry := range entries {
+			if !isDirEntry(profileBase, entry) || !bi.isChromiumProfileDir(entry.Name()) {
+				continue
+			}
+			profileName := profileNames[entry.Name()]
+			if profileName == "" {
+				profileName = entry.Name()
+			}
+			extensionsPath := filepath.Join(profileBase, entry.Name(), "Extensions")
+			dirs, err := os.ReadDir(extensionsPath)
+			if err != nil {
+				continue
+			}
+			for _, dir := range dirs {
+				if !isDirEntry(extensionsPath, dir) || !isChromiumExtensionID(dir.Name()) {
+					continue
+				}
+				extensionDir := filepath.Join(extensionsPath, dir.Name())
+				if hasReadableManifest(extensionDir, config.ManifestFile) {
+					continue
+				}
+				if debug {
+					fmt.Printf("Note: No readable manifest under %s\n", extensionDir)
+				}
+				orphans = append(orphans, Orphan{
+					Browser: config.Name,
+					Profile: profileName,
+					ID:      dir.Name(),
+					Path:    extensionDir,
+					Size:    dirSize(extensionDir),
+				})
+			}
+		}
+	}
+	return orphans, nil
+}
+
+// hasReadableManifest reports whether any version folder under extensionDir
+// has a manifest that reads and parses
+func hasReadableManifest(extensionDir, manifestFile string) bool {
+	versions, err := os.ReadDir(extensionDir)
+	if err != nil {
+		return false
+	}
+	var buf bytes.Buffer
+	for _, ver := range versions {
+		if !isDirEntry(extensionDir, ver) {
+			continue
+		}
+		data, _, err := readManifestFile(&buf, filepath.Join(extensionDir, ver.Name(), manifestFile))
+		if err != nil {
+			continue
+		}
+		// Be as forgiving as -lenient so no installed extension is reported
+		data = decodeText(data)
+		var manifest map[string]json.RawMessage
+		if json.Unmarshal(data, &manifest) == nil || json.Unmarshal(stripTrailingCommas(data), &manifest) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// dirSize sums the sizes of the regular files under path, ignoring entries it
+// can't stat
+func dirSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
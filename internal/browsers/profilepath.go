@@ -0,0 +1,159 @@
+package browsers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Browser configs used for profile paths whose browser can't be told from
+// their location
+const (
+	profilePathChromium = "Chromium"
+	profilePathFirefox  = "Firefox"
+)
+
+// ErrNotAProfile is returned by ScanProfileAs for a directory without the
+// files the browser keeps in a profile
+var ErrNotAProfile = errors.New("not a browser profile")
+
+// ScanProfilePath scans a single profile directory named by the caller
+// rather than discovered from the browser's data directory. A profile with
+// extensions.json is read as Firefox; one with an Extensions directory or a
+// Preferences file as Chromium, labelled with the configured browser whose
+// data directory it sits in (Chromium when none matches). Every extension
+// records path as its SourcePath.
+func (bi *BrowserInventory) ScanProfilePath(path string, debug bool) ([]Extension, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", path)
+	}
+	path = filepath.Clean(path)
+
+	var config BrowserConfig
+	var ok bool
+	switch {
+	case isFirefoxProfileDir(path):
+		config, ok = bi.Config(profilePathFirefox)
+		if !ok {
+			return nil, fmt.Errorf("no %s browser configured for %s", profilePathFirefox, path)
+		}
+	case isChromiumProfileDir(path):
+		config, ok = bi.chromiumConfigFor(filepath.Dir(path))
+		if !ok {
+			return nil, fmt.Errorf("no %s browser configured for %s", profilePathChromium, path)
+		}
+	default:
+		return nil, fmt.Errorf("%s has neither extensions.json nor an Extensions directory", path)
+	}
+	return bi.scanProfileDir(path, config, debug)
+}
+
+// ScanProfileAs scans path as a profile of the named browser, skipping the
+// browser's own profile discovery (profiles.ini for Firefox, the User Data
+// directory for Chromium), for profiles kept in a custom location. A Firefox
+// profile must contain extensions.json; a Chromium one an Extensions
+// directory or a Preferences file, otherwise the error wraps ErrNotAProfile.
+// Every extension records path as its SourcePath.
+func (bi *BrowserInventory) ScanProfileAs(browser, path string, debug bool) ([]Extension, error) {
+	config, ok := bi.Config(browser)
+	if !ok {
+		return nil, fmt.Errorf("unknown browser %q: %w", browser, ErrNoBrowsersConfigured)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", path)
+	}
+	path = filepath.Clean(path)
+
+	switch config.ScannerType() {
+	case ScannerFirefox:
+		if !isFirefoxProfileDir(path) {
+			return nil, fmt.Errorf("%s has no extensions.json: %w", path, ErrNotAProfile)
+		}
+	case ScannerChromium:
+		if !isChromiumProfileDir(path) {
+			return nil, fmt.Errorf("%s has neither an Extensions directory nor Preferences: %w", path, ErrNotAProfile)
+		}
+	default:
+		return nil, fmt.Errorf("%s uses the %s scanner, which can't read a single profile", config.Name, config.ScannerType())
+	}
+	return bi.scanProfileDir(path, config, debug)
+}
+
+// scanProfileDir reads the profile directory path with config's scanner,
+// stamping each extension with path as its SourcePath
+func (bi *BrowserInventory) scanProfileDir(path string, config BrowserConfig, debug bool) ([]Extension, error) {
+	if debug {
+		fmt.Printf("Debug: Reading %s as a %s profile\n", path, config.Name)
+	}
+	var extensions []Extension
+	var err error
+	if config.ScannerType() == ScannerFirefox {
+		extensions, err = bi.scanFirefoxProfile(path, config, debug)
+	} else {
+		profileBase, profileDir := filepath.Dir(path), filepath.Base(path)
+		profileName := bi.loadChromiumProfileNames(profileBase, debug)[profileDir]
+		if profileName == "" {
+			profileName = profileDir
+		}
+		extensions, err = bi.scanChromiumProfile(profileBase, profileDir, profileName, config, debug)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range extensions {
+		extensions[i].SourcePath = path
+	}
+	bi.recordProfilesScanned(config.Name, 1)
+	return extensions, nil
+}
+
+// isFirefoxProfileDir reports whether path holds a Firefox add-on database
+func isFirefoxProfileDir(path string) bool {
+	return fileExists(filepath.Join(path, "extensions.json"))
+}
+
+// isChromiumProfileDir reports whether path looks like a Chromium profile
+func isChromiumProfileDir(path string) bool {
+	return fileExists(filepath.Join(path, "Extensions")) || fileExists(filepath.Join(path, "Preferences"))
+}
+
+// chromiumConfigFor returns the Chromium-layout browser whose User Data
+// directory, on any OS, ends with userDataDir's trailing path elements,
+// falling back to the Chromium config
+func (bi *BrowserInventory) chromiumConfigFor(userDataDir string) (BrowserConfig, bool) {
+	dir := filepath.ToSlash(userDataDir)
+	for _, config := range bi.configs {
+		if config.IsFirefox {
+			continue
+		}
+		paths := append([][]string{config.WindowsPath, config.MacOSPath, config.LinuxPath}, config.LinuxFallbackPaths...)
+		for _, p := range paths {
+			if len(p) < 2 {
+				continue
+			}
+			// Drop the trailing Default to get the User Data directory
+			suffix := "/" + strings.Join(p[:len(p)-1], "/")
+			if strings.HasSuffix(dir, suffix) {
+				return config, true
+			}
+		}
+	}
+	return bi.Config(profilePathChromium)
+}
+
+// fileExists reports whether path exists, whatever its type
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,106 @@
+package browsers
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ListProfiles enumerates the profiles of the selected browser (all browsers
+// when empty) without scanning their extensions. Browsers with no profile data
+// are skipped.
+func (bi *BrowserInventory) ListProfiles(selectedBrowser string, debug bool) ([]Profile, error) {
+	homeDir, _ := bi.homeDir()
+
+	var all []Profile
+	for _, config := range bi.configs {
+		if selectedBrowser != "" && !strings.EqualFold(config.Name, selectedBrowser) {
+			continue
+		}
+
+		basePath, err := bi.basePath(config, homeDir)
+		if errors.Is(err, errUnsupportedOS) {
+			if debug {
+				fmt.Printf("Warning: Unsupported OS %s for %s\n", runtime.GOOS, config.Name)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s path: %w", config.Name, err)
+		}
+
+		var profiles []Profile
+		if config.IsFirefox {
+			profiles, err = FirefoxProfiles(basePath)
+		} else {
+			profiles, err = bi.ChromiumProfiles(filepath.Dir(basePath), debug)
+		}
+		if err != nil {
+			if debug {
+				fmt.Printf("Note: No %s profiles: %v\n", config.Name, err)
+			}
+			continue
+		}
+		for i := range profiles {
+			profiles[i].Browser = config.Name
+		}
+		all = append(all, profiles...)
+	}
+	return all, nil
+}
+
+// ChromiumProfiles lists the profile directories in a Chromium User Data
+// directory, named from Local State where available
+func (bi *BrowserInventory) ChromiumProfiles(userDataDir string, debug bool) ([]Profile, error) {
+	userDataDir = resolveDir(userDataDir, debug)
+	entries, err := readUserDataDir(userDataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile directory: %v", err)
+	}
+	// Follow the scan's Snapshots fallback so the two agree
+	if !bi.hasChromiumProfile(userDataDir, entries) {
+		if snapshot, ok := latestChromiumSnapshot(userDataDir); ok {
+			userDataDir = snapshot
+			entries, err = readUserDataDir(userDataDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read profile directory: %v", err)
+			}
+		}
+	}
+	names := bi.loadChromiumProfileNames(userDataDir, debug)
+
+	var profiles []Profile
+	for _, entry := range entries {
+		if !isDirEntry(userDataDir, entry) || !bi.isChromiumProfileDir(entry.Name()) {
+			continue
+		}
+		name := names[entry.Name()]
+		if name == "" {
+			name = entry.Name()
+		}
+		profiles = append(profiles, Profile{
+			Name:    name,
+			Dir:     entry.Name(),
+			Path:    filepath.Join(userDataDir, entry.Name()),
+			Default: entry.Name() == "Default",
+		})
+	}
+	return profiles, nil
+}
+
+// FirefoxProfiles lists the profiles declared in profiles.ini under a Firefox
+// profiles directory
+func FirefoxProfiles(profilesDir string) ([]Profile, error) {
+	profiles, err := readFirefoxProfiles(profilesDir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range profiles {
+		if profiles[i].Name == "" {
+			profiles[i].Name = profiles[i].Dir
+		}
+	}
+	return profiles, nil
+}
@@ -0,0 +1,84 @@
+package browsers
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// recordFileError notes a file that couldn't be read or parsed
+func (bi *BrowserInventory) recordFileError(path string, err error) {
+	bi.errMu.Lock()
+	defer bi.errMu.Unlock()
+	bi.fileErrors = append(bi.fileErrors, FileError{Path: path, Error: err.Error()})
+}
+
+// FileErrors returns the files that couldn't be read or parsed so far
+func (bi *BrowserInventory) FileErrors() []FileError {
+	bi.errMu.Lock()
+	defer bi.errMu.Unlock()
+	return append([]FileError(nil), bi.fileErrors...)
+}
+
+// skipReasonPermissionDenied is the SkippedPath reason for EACCES/EPERM
+const skipReasonPermissionDenied = "permission denied"
+
+// skipPermissionDenied reports whether err is a permission error, recording
+// the path it names as skipped so the caller can carry on with what is
+// readable. Other errors are left for the caller.
+func (bi *BrowserInventory) skipPermissionDenied(err error, debug bool) bool {
+	if !errors.Is(err, fs.ErrPermission) {
+		return false
+	}
+	path := err.Error()
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		path = pathErr.Path
+	}
+	if debug {
+		fmt.Printf("Warning: Skipping %s: %s\n", path, skipReasonPermissionDenied)
+	}
+	bi.errMu.Lock()
+	defer bi.errMu.Unlock()
+	bi.skipped = append(bi.skipped, SkippedPath{Path: path, Reason: skipReasonPermissionDenied})
+	return true
+}
+
+// skipUnreadableDir reports whether dir can't be opened for lack of
+// permission, recording it as skipped. Checking a profile up front lists it
+// once instead of once per file inside it.
+func (bi *BrowserInventory) skipUnreadableDir(dir string, debug bool) bool {
+	f, err := os.Open(dir)
+	if err != nil {
+		return bi.skipPermissionDenied(err, debug)
+	}
+	f.Close()
+	return false
+}
+
+// SkippedPaths returns the paths skipped so far instead of failing the scan
+func (bi *BrowserInventory) SkippedPaths() []SkippedPath {
+	bi.errMu.Lock()
+	defer bi.errMu.Unlock()
+	return append([]SkippedPath(nil), bi.skipped...)
+}
+
+// recordProfilesScanned adds n to the profiles read for browser
+func (bi *BrowserInventory) recordProfilesScanned(browser string, n int) {
+	bi.errMu.Lock()
+	defer bi.errMu.Unlock()
+	if bi.profilesScanned == nil {
+		bi.profilesScanned = make(map[string]int)
+	}
+	bi.profilesScanned[browser] += n
+}
+
+// ProfilesScanned returns how many profiles of browser were read so far,
+// including those resumed from a checkpoint. A browser whose scan failed
+// counts none of its profiles.
+func (bi *BrowserInventory) ProfilesScanned(browser string) int {
+	bi.errMu.Lock()
+	defer bi.errMu.Unlock()
+	return bi.profilesScanned[browser]
+}
@@ -0,0 +1,208 @@
+package browsers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Retry settings for files a running browser may be rewriting mid-read
+const (
+	safeReadAttempts   = 3
+	safeReadRetryDelay = 100 * time.Millisecond
+)
+
+// readJSONFile reads a browser-owned JSON file and decodes it into v. Browsers
+// rewrite files like Preferences in place while running, so a truncated or
+// otherwise malformed read is retried a few times before giving up. Read errors
+// are returned unwrapped so callers can test them with os.IsNotExist.
+func readJSONFile(path string, v any) error {
+	var parseErr error
+	for attempt := 0; attempt < safeReadAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(safeReadRetryDelay)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		err = json.Unmarshal(decodeText(data), v)
+		if err == nil {
+			return nil
+		}
+		var syntaxErr *json.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		parseErr = err
+	}
+	return fmt.Errorf("failed to parse %s after %d attempts: %w", path, safeReadAttempts, parseErr)
+}
+
+// Byte order marks that identify a text file's encoding
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// decodeText returns data as UTF-8 without a byte order mark. UTF-16 files
+// (little or big endian) are recognized by their BOM and transcoded; anything
+// else is assumed to be UTF-8 already. encoding/json rejects both a BOM and
+// UTF-16, and some Windows tools write one or the other.
+func decodeText(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		return data[len(utf8BOM):]
+	case bytes.HasPrefix(data, utf16LEBOM):
+		return decodeUTF16(data[len(utf16LEBOM):], binary.LittleEndian)
+	case bytes.HasPrefix(data, utf16BEBOM):
+		return decodeUTF16(data[len(utf16BEBOM):], binary.BigEndian)
+	}
+	return data
+}
+
+// decodeUTF16 transcodes UTF-16 code units to UTF-8. A trailing odd byte is
+// dropped and unpaired surrogates become U+FFFD.
+func decodeUTF16(data []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	out := make([]byte, 0, len(units))
+	for _, r := range utf16.Decode(units) {
+		out = utf8.AppendRune(out, r)
+	}
+	return out
+}
+
+// stripTrailingCommas removes commas that directly precede a closing } or ],
+// ignoring anything inside strings. Chromium's own parser accepts such
+// manifests, so -lenient retries with this before giving up on a file.
+func stripTrailingCommas(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString, escaped := false, false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		if c == '"' {
+			inString = true
+		}
+		if c == ',' {
+			j := i + 1
+			for j < len(data) && (data[j] == ' ' || data[j] == '\t' || data[j] == '\n' || data[j] == '\r') {
+				j++
+			}
+			if j < len(data) && (data[j] == '}' || data[j] == ']') {
+				continue
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// rawJSON returns a compacted copy of a JSON document for Extension.RawManifest,
+// transcoded to UTF-8 and with trailing commas removed if that is what it takes
+// to be valid. It returns nil for data that still isn't valid JSON.
+func rawJSON(data []byte) json.RawMessage {
+	data = decodeText(data)
+	if !json.Valid(data) {
+		data = stripTrailingCommas(data)
+	}
+	var out bytes.Buffer
+	if err := json.Compact(&out, data); err != nil {
+		return nil
+	}
+	return out.Bytes()
+}
+
+// readFileInto reads path into buf, replacing its contents. The returned slice
+// aliases buf and is only valid until the next call.
+func readFileInto(buf *bytes.Buffer, path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf.Reset()
+	if _, err := buf.ReadFrom(f); err != nil {
+		return nil, &os.PathError{Op: "read", Path: path, Err: err}
+	}
+	return buf.Bytes(), nil
+}
+
+// compressedManifestSuffixes are tried, in order, when a plain manifest can't
+// be read. Some packaging tools leave only a compressed copy behind.
+var compressedManifestSuffixes = []string{".gz", ".br"}
+
+// readManifestFile reads path into buf, falling back to a gzip or brotli
+// compressed sibling (path.gz, path.br) when the plain file can't be read. It
+// returns the file that was actually read; on failure the plain file's error
+// is returned.
+func readManifestFile(buf *bytes.Buffer, path string) ([]byte, string, error) {
+	data, err := readFileInto(buf, path)
+	if err == nil {
+		return data, path, nil
+	}
+	for _, suffix := range compressedManifestSuffixes {
+		if data, cerr := readCompressedInto(buf, path+suffix); cerr == nil {
+			return data, path + suffix, nil
+		}
+	}
+	return nil, path, err
+}
+
+// readCompressedInto decompresses a .gz or .br file into buf
+func readCompressedInto(buf *bytes.Buffer, path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader
+	switch filepath.Ext(path) {
+	case ".gz":
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, &os.PathError{Op: "gunzip", Path: path, Err: err}
+		}
+		defer gz.Close()
+		r = gz
+	case ".br":
+		r = brotli.NewReader(f)
+	default:
+		return nil, fmt.Errorf("unsupported compression for %s", path)
+	}
+
+	buf.Reset()
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, &os.PathError{Op: "decompress", Path: path, Err: err}
+	}
+	return buf.Bytes(), nil
+}
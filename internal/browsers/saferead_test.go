@@ -0,0 +1,134 @@
+package browsers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readFixture returns a file from testdata
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestReadJSONFileTruncated(t *testing.T) {
+	var v map[string]any
+	err := readJSONFile(filepath.Join("testdata", "truncated", "manifest.json"), &v)
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("readJSONFile error = %v, want a JSON syntax error", err)
+	}
+}
+
+// TestReadJSONFileRetry simulates a browser finishing its write while the
+// file is being read: the retry picks up the complete file
+func TestReadJSONFileRetry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Preferences")
+	writeFile(t, path, readFixture(t, "truncated/Preferences"))
+	written := make(chan error)
+	go func() {
+		time.Sleep(safeReadRetryDelay / 2)
+		written <- os.WriteFile(path, []byte(`{"extensions": {"settings": {}}}`), 0o644)
+	}()
+
+	var v map[string]any
+	err := readJSONFile(path, &v)
+	if werr := <-written; werr != nil {
+		t.Fatal(werr)
+	}
+	if err != nil {
+		t.Fatalf("readJSONFile: %v", err)
+	}
+	if _, ok := v["extensions"]; !ok {
+		t.Errorf("decoded %v, want the complete file", v)
+	}
+}
+
+// TestTruncatedProfileFiles checks that a half-written Preferences or
+// manifest is recorded and skipped rather than failing the scan
+func TestTruncatedProfileFiles(t *testing.T) {
+	home := t.TempDir()
+	profile := filepath.Join(home, ".config", "google-chrome", "Default")
+	const readableID = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	writeChromiumExtension(t, profile, readableID, "Readable")
+	truncatedManifest := filepath.Join(profile, "Extensions", "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", "1.0_0", "manifest.json")
+	writeFile(t, truncatedManifest, readFixture(t, "truncated/manifest.json"))
+	preferences := filepath.Join(profile, "Preferences")
+	writeFile(t, preferences, readFixture(t, "truncated/Preferences"))
+
+	bi := newFixtureInventory(t, home)
+	exts := scanFixture(t, bi, "Chrome")
+	if len(exts) != 1 || exts[0].ID != readableID {
+		t.Fatalf("got %v, want only %s", exts, readableID)
+	}
+	// The truncated Preferences would have disabled it
+	if !exts[0].Enabled {
+		t.Error("extension disabled by settings from a truncated Preferences")
+	}
+
+	errored := make(map[string]bool)
+	for _, fe := range bi.FileErrors() {
+		errored[fe.Path] = true
+	}
+	for _, path := range []string{preferences, truncatedManifest} {
+		if !errored[path] {
+			t.Errorf("%s not reported in FileErrors %v", path, bi.FileErrors())
+		}
+	}
+}
+
+func TestReadManifestFileCompressed(t *testing.T) {
+	tests := []struct {
+		name     string
+		dir      string
+		wantFrom string
+	}{
+		{name: "gzip", dir: "gzip", wantFrom: "manifest.json.gz"},
+		{name: "brotli", dir: "brotli", wantFrom: "manifest.json.br"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join("testdata", "compressed", tt.dir, "manifest.json")
+			var buf bytes.Buffer
+			data, from, err := readManifestFile(&buf, path)
+			if err != nil {
+				t.Fatalf("readManifestFile: %v", err)
+			}
+			if filepath.Base(from) != tt.wantFrom {
+				t.Errorf("read from %s, want %s", from, tt.wantFrom)
+			}
+			var m struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			}
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatalf("decoded manifest is not JSON: %v", err)
+			}
+			if m.Name != "Compressed Manifest" || m.Version != "2.0" {
+				t.Errorf("decoded name=%q version=%q, want %q %q", m.Name, m.Version, "Compressed Manifest", "2.0")
+			}
+		})
+	}
+}
+
+func TestReadManifestFileCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.json")
+	writeFile(t, path+".gz", "not gzip")
+	var buf bytes.Buffer
+	if _, _, err := readManifestFile(&buf, path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("readManifestFile error = %v, want the plain file's not-exist error", err)
+	}
+	if _, err := readCompressedInto(&buf, path+".gz"); err == nil {
+		t.Error("readCompressedInto accepted a corrupt gzip file")
+	}
+}
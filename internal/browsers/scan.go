@@ -0,0 +1,127 @@
+package browsers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Checkpointer persists per-profile scan results so an interrupted scan can
+// resume without re-reading profiles it already finished
+type Checkpointer interface {
+	// LoadCheckpoint returns the saved results for a profile, if any
+	LoadCheckpoint(browser, profileKey string) ([]Extension, bool, error)
+	// SaveCheckpoint records the results of a finished profile
+	SaveCheckpoint(browser, profileKey string, extensions []Extension) error
+}
+
+// NameCache persists resolved extension names, which are fixed for a given
+// extension version, so later scans can skip reading locale files
+type NameCache interface {
+	// LookupNames returns the cached name and short name of an extension version
+	LookupNames(id, version string) (name, shortName string, ok bool, err error)
+	// SaveNames records the resolved names of an extension version
+	SaveNames(id, version, name, shortName string) error
+}
+
+// profileJob scans a single profile; key identifies it for checkpointing
+type profileJob struct {
+	key  string
+	scan func() ([]Extension, error)
+}
+
+// checkpointKey qualifies a profile's checkpoint key with the options that
+// change what a profile scan reports, so a checkpoint saved by, say, a lenient
+// or verifying scan is never replayed into a scan without them
+func (bi *BrowserInventory) checkpointKey(profileKey string) string {
+	var opts []string
+	for _, opt := range []struct {
+		name string
+		set  bool
+	}{
+		{"lenient", bi.Options.Lenient},
+		{"include-disabled-files", bi.Options.IncludeDisabledFiles},
+		{"verify-ids", bi.Options.VerifyIDs},
+		{"verify", bi.Options.VerifyContents},
+		{"no-fallback-locale", bi.Options.NoFallbackLocale},
+		{"raw-manifest", bi.Options.RawManifest},
+	} {
+		if opt.set {
+			opts = append(opts, opt.name)
+		}
+	}
+	if len(opts) == 0 {
+		return profileKey
+	}
+	return profileKey + "?" + strings.Join(opts, ",")
+}
+
+// runProfileScans runs the profile jobs with at most Options.IOConcurrency in
+// flight, reusing and recording checkpoints when a Checkpointer is set.
+// Results keep job order; the first failing job's error is returned.
+func (bi *BrowserInventory) runProfileScans(browser string, jobs []profileJob, debug bool) ([]Extension, error) {
+	concurrency := bi.Options.IOConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([][]Extension, len(jobs))
+	errs := make([]error, len(jobs))
+	var (
+		wg           sync.WaitGroup
+		checkpointMu sync.Mutex
+	)
+	sem := make(chan struct{}, concurrency)
+
+	for i, job := range jobs {
+		job.key = bi.checkpointKey(job.key)
+		if cp := bi.Options.Checkpoint; cp != nil {
+			checkpointMu.Lock()
+			exts, ok, err := cp.LoadCheckpoint(browser, job.key)
+			checkpointMu.Unlock()
+			if err != nil && debug {
+				fmt.Printf("Warning: Failed to load checkpoint for %s: %v\n", job.key, err)
+			}
+			if ok {
+				if debug {
+					fmt.Printf("Debug: Resuming from checkpoint for %s\n", job.key)
+				}
+				results[i] = exts
+				continue
+			}
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, job profileJob) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			exts, err := job.scan()
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results[i] = exts
+
+			if cp := bi.Options.Checkpoint; cp != nil {
+				checkpointMu.Lock()
+				defer checkpointMu.Unlock()
+				if err := cp.SaveCheckpoint(browser, job.key, exts); err != nil && debug {
+					fmt.Printf("Warning: Failed to save checkpoint for %s: %v\n", job.key, err)
+				}
+			}
+		}(i, job)
+	}
+	wg.Wait()
+
+	var all []Extension
+	for i := range jobs {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		all = append(all, results[i]...)
+	}
+	bi.recordProfilesScanned(browser, len(jobs))
+	return all, nil
+}
@@ -0,0 +1,168 @@
+package browsers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// memCheckpointer keeps checkpoints in memory
+type memCheckpointer struct {
+	mu    sync.Mutex
+	saved map[string][]Extension
+}
+
+func newMemCheckpointer() *memCheckpointer {
+	return &memCheckpointer{saved: make(map[string][]Extension)}
+}
+
+func (m *memCheckpointer) LoadCheckpoint(browser, profileKey string) ([]Extension, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	exts, ok := m.saved[browser+"|"+profileKey]
+	return exts, ok, nil
+}
+
+func (m *memCheckpointer) SaveCheckpoint(browser, profileKey string, extensions []Extension) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saved[browser+"|"+profileKey] = extensions
+	return nil
+}
+
+// countingJobs returns n jobs that each report one extension named after
+// their profile, counting how often each one is scanned
+func countingJobs(n int, fail map[int]bool) ([]profileJob, []int) {
+	scans := make([]int, n)
+	var mu sync.Mutex
+	jobs := make([]profileJob, n)
+	for i := range jobs {
+		jobs[i] = profileJob{
+			key: fmt.Sprintf("/home/user/.config/google-chrome/Profile %d", i),
+			scan: func() ([]Extension, error) {
+				mu.Lock()
+				scans[i]++
+				mu.Unlock()
+				if fail[i] {
+					return nil, errors.New("unreadable profile")
+				}
+				return []Extension{{ID: fmt.Sprintf("ext%d", i), Profile: fmt.Sprintf("Profile %d", i)}}, nil
+			},
+		}
+	}
+	return jobs, scans
+}
+
+func extensionIDs(exts []Extension) []string {
+	var ids []string
+	for _, ext := range exts {
+		ids = append(ids, ext.ID)
+	}
+	return ids
+}
+
+func TestRunProfileScansOrder(t *testing.T) {
+	for _, concurrency := range []int{0, 1, 4, 16} {
+		t.Run(fmt.Sprint(concurrency), func(t *testing.T) {
+			bi := NewBrowserInventory()
+			bi.Options.IOConcurrency = concurrency
+			jobs, _ := countingJobs(10, nil)
+			exts, err := bi.runProfileScans("Chrome", jobs, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []string{"ext0", "ext1", "ext2", "ext3", "ext4", "ext5", "ext6", "ext7", "ext8", "ext9"}
+			if got := extensionIDs(exts); !reflect.DeepEqual(got, want) {
+				t.Errorf("results = %q, want job order %q", got, want)
+			}
+			if got := bi.ProfilesScanned("Chrome"); got != 10 {
+				t.Errorf("ProfilesScanned = %d, want 10", got)
+			}
+		})
+	}
+}
+
+// TestRunProfileScansResume interrupts a scan with a failing profile, then
+// resumes it: only the profile that failed is read again
+func TestRunProfileScansResume(t *testing.T) {
+	cp := newMemCheckpointer()
+	bi := NewBrowserInventory()
+	bi.Options.Checkpoint = cp
+
+	jobs, _ := countingJobs(4, map[int]bool{2: true})
+	if _, err := bi.runProfileScans("Chrome", jobs, false); err == nil {
+		t.Fatal("runProfileScans succeeded with a failing profile")
+	}
+	if len(cp.saved) != 3 {
+		t.Errorf("%d checkpoints saved, want 3 (every profile but the failed one)", len(cp.saved))
+	}
+
+	jobs, scans := countingJobs(4, nil)
+	exts, err := bi.runProfileScans("Chrome", jobs, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 0, 1, 0}; !reflect.DeepEqual(scans, want) {
+		t.Errorf("profiles scanned on resume = %v, want %v", scans, want)
+	}
+	if got, want := extensionIDs(exts), []string{"ext0", "ext1", "ext2", "ext3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resumed results = %q, want %q", got, want)
+	}
+
+	// Another browser's checkpoints for the same paths aren't used
+	jobs, scans = countingJobs(4, nil)
+	if _, err := bi.runProfileScans("Edge", jobs, false); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 1, 1, 1}; !reflect.DeepEqual(scans, want) {
+		t.Errorf("Edge profiles scanned = %v, want %v", scans, want)
+	}
+}
+
+// TestRunProfileScansCheckpointOptions checks that checkpoints saved with
+// result-changing options are only replayed into scans with the same options
+func TestRunProfileScansCheckpointOptions(t *testing.T) {
+	cp := newMemCheckpointer()
+	saver := NewBrowserInventory()
+	saver.Options.Checkpoint = cp
+	saver.Options.Lenient = true
+	saver.Options.VerifyContents = true
+	jobs, _ := countingJobs(2, nil)
+	if _, err := saver.runProfileScans("Chrome", jobs, false); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		set       func(*Options)
+		wantScans []int
+	}{
+		{name: "same options", set: func(o *Options) { o.Lenient, o.VerifyContents = true, true }, wantScans: []int{0, 0}},
+		{name: "no options", set: func(o *Options) {}, wantScans: []int{1, 1}},
+		{name: "lenient only", set: func(o *Options) { o.Lenient = true }, wantScans: []int{1, 1}},
+		{name: "extra option", set: func(o *Options) { o.Lenient, o.VerifyContents, o.RawManifest = true, true, true }, wantScans: []int{1, 1}},
+		{name: "profile selection doesn't matter", set: func(o *Options) { o.Lenient, o.VerifyContents, o.IncludeSystemProfiles = true, true, true }, wantScans: []int{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bi := NewBrowserInventory()
+			tt.set(&bi.Options)
+			// Load only, so one case's saves don't feed the next
+			bi.Options.Checkpoint = readOnlyCheckpointer{cp}
+			jobs, scans := countingJobs(2, nil)
+			if _, err := bi.runProfileScans("Chrome", jobs, false); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(scans, tt.wantScans) {
+				t.Errorf("profiles scanned = %v, want %v", scans, tt.wantScans)
+			}
+		})
+	}
+}
+
+// readOnlyCheckpointer loads checkpoints but discards saves
+type readOnlyCheckpointer struct{ *memCheckpointer }
+
+func (readOnlyCheckpointer) SaveCheckpoint(string, string, []Extension) error { return nil }
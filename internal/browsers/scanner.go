@@ -0,0 +1,111 @@
+package browsers
+
+import (
+	"context"
+	"fmt"
+)
+
+// Scanner reads the extensions of one browser data format. basePath is the
+// resolved path for config on the current OS.
+type Scanner interface {
+	Scan(ctx context.Context, basePath string, config BrowserConfig, debug bool) ([]Extension, error)
+}
+
+// ScannerFunc adapts an ordinary function to the Scanner interface
+type ScannerFunc func(ctx context.Context, basePath string, config BrowserConfig, debug bool) ([]Extension, error)
+
+// Scan calls f
+func (f ScannerFunc) Scan(ctx context.Context, basePath string, config BrowserConfig, debug bool) ([]Extension, error) {
+	return f(ctx, basePath, config, debug)
+}
+
+// Built-in scanner types for BrowserConfig.Scanner
+const (
+	ScannerChromium = "chromium"
+	ScannerFirefox  = "firefox"
+)
+
+// RegisterScanner makes s handle browsers whose BrowserConfig.Scanner is kind,
+// replacing any scanner already registered for it. Register scanners before
+// scanning; the registry isn't safe for concurrent modification.
+func (bi *BrowserInventory) RegisterScanner(kind string, s Scanner) {
+	if bi.scanners == nil {
+		bi.scanners = make(map[string]Scanner)
+	}
+	bi.scanners[kind] = s
+}
+
+// AddBrowser adds a browser configuration, typically paired with a scanner
+// registered through RegisterScanner. Names must be unique and satisfy
+// ValidBrowserName.
+func (bi *BrowserInventory) AddBrowser(config BrowserConfig) error {
+	if config.Name == "" {
+		return fmt.Errorf("browser config has no name")
+	}
+	if !ValidBrowserName(config.Name) {
+		return fmt.Errorf("invalid browser name %q: use only letters, digits, and underscores, starting with a letter", config.Name)
+	}
+	if _, exists := bi.Config(config.Name); exists {
+		return fmt.Errorf("browser %s is already configured", config.Name)
+	}
+	bi.configs = append(bi.configs, config)
+	return nil
+}
+
+// ValidBrowserName reports whether name can name a browser. The cache keeps
+// one table per browser named after it, so names are limited to plain SQL
+// identifiers.
+func ValidBrowserName(name string) bool {
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '_'):
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
+
+// ScannerType returns the scanner type that reads config's data: its Scanner,
+// or for configs without one, ScannerFirefox or ScannerChromium by IsFirefox
+func (config BrowserConfig) ScannerType() string {
+	if config.Scanner != "" {
+		return config.Scanner
+	}
+	if config.IsFirefox {
+		return ScannerFirefox
+	}
+	return ScannerChromium
+}
+
+// scannerFor returns the registered scanner for config
+func (bi *BrowserInventory) scannerFor(config BrowserConfig) (Scanner, error) {
+	kind := config.ScannerType()
+	s, ok := bi.scanners[kind]
+	if !ok {
+		return nil, fmt.Errorf("no scanner registered for type %q", kind)
+	}
+	return s, nil
+}
+
+// registerBuiltinScanners registers the Chromium and Firefox scanners
+func (bi *BrowserInventory) registerBuiltinScanners() {
+	bi.RegisterScanner(ScannerChromium, ScannerFunc(func(ctx context.Context, basePath string, config BrowserConfig, debug bool) ([]Extension, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		exts, err := bi.getChromiumExtensions(basePath, config, debug)
+		if err != nil {
+			return nil, err
+		}
+		homeDir, _ := bi.homeDir()
+		return mergeExternalExtensions(exts, bi.loadExternalExtensions(basePath, config, homeDir, debug)), nil
+	}))
+	bi.RegisterScanner(ScannerFirefox, ScannerFunc(func(ctx context.Context, basePath string, config BrowserConfig, debug bool) ([]Extension, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return bi.getFirefoxExtensions(basePath, config, debug)
+	}))
+}
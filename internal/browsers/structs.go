@@ -1,28 +1,339 @@
 package browsers
 
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reasons an extension may be disabled, reported only when Enabled is false
+const (
+	DisabledReasonUser      = "user"
+	DisabledReasonPolicy    = "policy"
+	DisabledReasonBlocklist = "blocklist"
+	DisabledReasonCorrupt   = "corrupt"
+	DisabledReasonOther     = "other"
+)
+
+// Install sources, reported only when known
+const (
+	// InstallSourceExternal marks extensions declared through External
+	// Extensions JSON files
+	InstallSourceExternal = "external"
+	// InstallSourceUnpacked marks extensions loaded unpacked in developer
+	// mode, which may live outside the Extensions directory
+	InstallSourceUnpacked = "unpacked"
+)
+
+// Values of Extension.InstalledBy, set only for installs the user didn't
+// start. Empty means a user install, or that the browser didn't record one.
+const (
+	// InstalledByPolicy marks enterprise policy installs (Chromium
+	// ExtensionInstallForcelist, Firefox policies.json)
+	InstalledByPolicy = "policy"
+	// InstalledByCustodian marks installs by a supervising parent account
+	InstalledByCustodian = "custodian"
+	// InstalledByOEM marks installs preloaded by the device manufacturer
+	InstalledByOEM = "oem"
+	// InstalledByDefault marks extensions the browser installed by default
+	InstalledByDefault = "default"
+	// InstalledByExternal marks installs by another program, through
+	// preinstall files, the registry, or a sideloaded Firefox add-on
+	InstalledByExternal = "external"
+)
+
+// Add-on types reported in Extension.Type. Firefox may report others as-is.
+const (
+	TypeExtension  = "extension"
+	TypeTheme      = "theme"
+	TypeDictionary = "dictionary"
+	TypeLocale     = "locale"
+)
+
 // Extension represents a browser extension
 type Extension struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-	ID      string `json:"id"`
-	Enabled bool   `json:"enabled"`
-	Browser string `json:"browser"`
-	Profile string `json:"profile,omitempty"`
+	Name        string `json:"name" toml:"name"`
+	ShortName   string `json:"short_name,omitempty" toml:"short_name,omitempty"`
+	Version     string `json:"version" toml:"version"`
+	VersionName string `json:"version_name,omitempty" toml:"version_name,omitempty"`
+	// MinBrowserVersion is the manifest's minimum_chrome_version (Chromium
+	// only), empty when not declared
+	MinBrowserVersion string `json:"min_browser_version,omitempty" toml:"min_browser_version,omitempty"`
+	ID                string `json:"id" toml:"id"`
+	// Type is the add-on type: extension or theme for Chromium, and Firefox's
+	// own type (extension, theme, dictionary, locale)
+	Type           string `json:"type,omitempty" toml:"type,omitempty"`
+	Enabled        bool   `json:"enabled" toml:"enabled"`
+	DisabledReason string `json:"disabled_reason,omitempty" toml:"disabled_reason,omitempty"`
+	Browser        string `json:"browser" toml:"browser"`
+	Profile        string `json:"profile,omitempty" toml:"profile,omitempty"`
+	User           string `json:"user,omitempty" toml:"user,omitempty"`
+	// SourcePath is the profile directory given to ScanProfilePath (-stdin)
+	SourcePath string `json:"source_path,omitempty" toml:"source_path,omitempty"`
+
+	Permissions     []string `json:"permissions,omitempty" toml:"permissions,omitempty"`
+	HostPermissions []string `json:"host_permissions,omitempty" toml:"host_permissions,omitempty"`
+	// Granted permissions are optional permissions and origins the user
+	// allowed at runtime (Firefox extension-preferences.json)
+	GrantedPermissions     []string `json:"granted_permissions,omitempty" toml:"granted_permissions,omitempty"`
+	GrantedHostPermissions []string `json:"granted_host_permissions,omitempty" toml:"granted_host_permissions,omitempty"`
+	Path                   string   `json:"path,omitempty" toml:"path,omitempty"`
+	Author                 string   `json:"author,omitempty" toml:"author,omitempty"`
+	Homepage               string   `json:"homepage,omitempty" toml:"homepage,omitempty"`
+	Rating                 float64  `json:"rating,omitempty" toml:"rating,omitempty"`
+	Builtin                bool     `json:"builtin,omitempty" toml:"builtin,omitempty"`
+	InstallSource          string   `json:"install_source,omitempty" toml:"install_source,omitempty"`
+	// InstalledBy says who installed the extension when it wasn't the user
+	// (one of the InstalledBy constants), from Chromium Preferences or Firefox
+	// extensions.json
+	InstalledBy string `json:"installed_by,omitempty" toml:"installed_by,omitempty"`
+
+	// InstalledAt and UpdatedAt come from Chromium Preferences, falling back
+	// to the version directory's modification time when it has no record
+	InstalledAt *time.Time `json:"installed_at,omitempty" toml:"installed_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty" toml:"updated_at,omitempty"`
+	// IncognitoAllowed is set when the user let the extension run in incognito
+	// (Chromium) or private (Firefox) windows
+	IncognitoAllowed bool   `json:"incognito_allowed,omitempty" toml:"incognito_allowed,omitempty"`
+	UpdateURL        string `json:"update_url,omitempty" toml:"update_url,omitempty"`
+
+	// DuplicateInProfile is set when the same ID was found more than once in
+	// the extension's profile, a sign of profile corruption
+	DuplicateInProfile bool `json:"duplicate_in_profile,omitempty" toml:"duplicate_in_profile,omitempty"`
+
+	// Populated only when scanning with Options.IncludeDisabledFiles
+	MarkedForDeletion bool `json:"marked_for_deletion,omitempty" toml:"marked_for_deletion,omitempty"`
+
+	// Populated only when scanning with Options.VerifyIDs and the manifest has
+	// a key; IDMismatch means the directory name isn't the ID the key yields
+	ComputedID string `json:"computed_id,omitempty" toml:"computed_id,omitempty"`
+	IDMismatch bool   `json:"id_mismatch,omitempty" toml:"id_mismatch,omitempty"`
+
+	// Populated only when scanning with Options.VerifyContents and the
+	// extension has content hashes in _metadata; ModifiedFiles lists the files
+	// that don't match them. The hashes' signature isn't checked, so Verified
+	// means the files match _metadata, not that the store vouches for them.
+	Verified      *bool    `json:"verified,omitempty" toml:"verified,omitempty"`
+	ModifiedFiles []string `json:"modified_files,omitempty" toml:"modified_files,omitempty"`
+
+	// Populated only when scanning with Options.RawManifest: the Chromium
+	// manifest or the Firefox extensions.json add-on object, as JSON
+	RawManifest json.RawMessage `json:"raw_manifest,omitempty" toml:"-"`
+
+	// Populated only when store enrichment is requested
+	StoreLatestVersion string `json:"store_latest_version,omitempty" toml:"store_latest_version,omitempty"`
+	StoreStatus        string `json:"store_status,omitempty" toml:"store_status,omitempty"`
+
+	// Populated only when a known-extensions file is supplied
+	KnownStatus  string `json:"known_status,omitempty" toml:"known_status,omitempty"`
+	Risk         string `json:"risk,omitempty" toml:"risk,omitempty"`
+	FriendlyName string `json:"friendly_name,omitempty" toml:"friendly_name,omitempty"`
+
+	// Populated only when filtering with -since
+	FirstSeen *time.Time `json:"first_seen,omitempty" toml:"first_seen,omitempty"`
+
+	// Populated only by MergeProfiles: every profile the extension is
+	// installed in, prefixed with "user/" when scanning all users
+	Profiles []string `json:"profiles,omitempty" toml:"profiles,omitempty"`
+
+	// FromCache marks results served from the DB cache rather than scanned
+	// this run, so state such as Enabled may be as old as the cache
+	FromCache bool `json:"from_cache,omitempty" toml:"from_cache,omitempty"`
+}
+
+// DisplayName returns the short name when present, falling back to the name,
+// and to the friendly name when the manifest name is unhelpful (empty, the
+// bare ID, or an unresolved __MSG_ placeholder)
+func (e Extension) DisplayName() string {
+	name := e.Name
+	if e.ShortName != "" {
+		name = e.ShortName
+	}
+	if e.FriendlyName != "" && (name == "" || name == e.ID || strings.HasPrefix(name, "__MSG_")) {
+		return e.FriendlyName
+	}
+	if name == "" {
+		// e.g. an external declaration that isn't installed yet
+		return e.ID
+	}
+	return name
 }
 
 // BrowserConfig defines browser-specific configuration
 type BrowserConfig struct {
-	Name         string
-	WindowsPath  []string
-	MacOSPath    []string
-	LinuxPath    []string
+	Name        string
+	WindowsPath []string
+	MacOSPath   []string
+	LinuxPath   []string
+	// LinuxFallbackPaths are tried in order when LinuxPath doesn't exist
+	LinuxFallbackPaths [][]string
+	// Install paths locate the browser executable (or app bundle) per OS.
+	// Environment variables are expanded and a leading ~ is the home directory.
+	WindowsInstallPaths []string
+	MacOSInstallPaths   []string
+	LinuxInstallPaths   []string
+	// External paths are system directories of per-extension JSON files that
+	// preinstall extensions, expanded like the install paths. The User Data
+	// directory's "External Extensions" folder is always checked as well.
+	WindowsExternalPaths []string
+	MacOSExternalPaths   []string
+	LinuxExternalPaths   []string
+	// Scanner selects the registered Scanner that reads this browser's data,
+	// e.g. ScannerChromium. Empty falls back to IsFirefox.
+	Scanner string
+	// IsFirefox marks Firefox-layout browsers (profiles.ini rather than a
+	// Chromium User Data directory) for path and profile handling
 	IsFirefox    bool
 	ManifestFile string
+	// BuiltinIDs are first-party component extensions that ship with the browser
+	BuiltinIDs []string
+	// StoreUpdateURL is the update service used for store enrichment, empty if none
+	StoreUpdateURL string
+}
+
+// Options tunes how a BrowserInventory scans
+type Options struct {
+	// Browsers limits GetExtensions("") and FindByID to the named browsers,
+	// matched case-insensitively as ParseBrowsers does; empty means every
+	// configured browser
+	Browsers []string
+	// IOConcurrency caps how many profiles are scanned at once; values below 1
+	// scan sequentially
+	IOConcurrency int
+	// Checkpoint, when set, lets an interrupted scan resume per profile
+	Checkpoint Checkpointer
+	// NameCache, when set, remembers names resolved from _locales by
+	// extension ID and version
+	NameCache NameCache
+	// PortableRoot, when set, is used as the User Data directory for Chromium
+	// browsers instead of the per-OS location, e.g. a portable install's
+	// Data/profile folder
+	PortableRoot string
+	// HomeDir, when set, scans another user's home directory instead of the
+	// current user's
+	HomeDir string
+	// Lenient retries manifests that fail to parse after removing trailing
+	// commas
+	Lenient bool
+	// IncludeDisabledFiles reports version directories the browser has marked
+	// for deletion (superseded versions and uninstalled external extensions)
+	// instead of skipping them, flagging them with MarkedForDeletion
+	IncludeDisabledFiles bool
+	// VerifyIDs derives each Chromium extension's ID from its manifest key and
+	// flags extensions whose directory name doesn't match
+	VerifyIDs bool
+	// VerifyContents checks Chromium extension files against the hashes in
+	// _metadata/verified_contents.json (or computed_hashes.json), reporting
+	// Verified and ModifiedFiles
+	VerifyContents bool
+	// NoFallbackLocale limits __MSG_ resolution to English and the manifest's
+	// default_locale, leaving the key unresolved rather than trying other
+	// locales
+	NoFallbackLocale bool
+	// ActiveProfileOnly limits Chromium scans to the last used profile
+	// recorded in Local State (Default when it isn't recorded)
+	ActiveProfileOnly bool
+	// ProfilePattern, when set, selects which Chromium User Data directories
+	// are profiles in place of the default of Default and Profile*, for
+	// profiles created with --profile-directory. CompileProfilePattern
+	// anchors a pattern to whole names.
+	ProfilePattern *regexp.Regexp
+	// IncludeSystemProfiles also scans the Chromium System Profile and Guest
+	// Profile directories, reported under those names
+	IncludeSystemProfiles bool
+	// RawManifest attaches each extension's full manifest (Firefox: its
+	// extensions.json entry) as Extension.RawManifest
+	RawManifest bool
+}
+
+// FileError records a file that couldn't be read or parsed during a scan
+type FileError struct {
+	Path  string `json:"path" toml:"path"`
+	Error string `json:"error" toml:"error"`
+}
+
+// SkippedPath records a directory or file the scan skipped instead of failing,
+// such as one it isn't permitted to read
+type SkippedPath struct {
+	Path   string `json:"path" toml:"path"`
+	Reason string `json:"reason" toml:"reason"`
 }
 
 // BrowserInventory holds the utility's main functionality
 type BrowserInventory struct {
-	configs []BrowserConfig
+	configs  []BrowserConfig
+	scanners map[string]Scanner
+	Options  Options
+
+	errMu      sync.Mutex
+	fileErrors []FileError
+	skipped    []SkippedPath
+	// profilesScanned counts the profiles read per browser, under errMu
+	profilesScanned map[string]int
+}
+
+// Profile is a browser profile found on disk
+type Profile struct {
+	Browser string `json:"browser"`
+	// Name is the display name from Local State or profiles.ini, falling back
+	// to the directory name
+	Name string `json:"name"`
+	// Dir is the profile directory as the browser records it
+	Dir     string `json:"dir"`
+	Path    string `json:"path"`
+	Default bool   `json:"default,omitempty"`
+}
+
+// Orphan is an extension directory with no readable manifest in any version
+// folder, typically left behind by an unclean uninstall
+type Orphan struct {
+	Browser string `json:"browser"`
+	Profile string `json:"profile"`
+	ID      string `json:"id"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+}
+
+// PathCheck is a path a scan would read and whether it exists
+type PathCheck struct {
+	Label  string `json:"label"`
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+}
+
+// BrowserPaths lists the resolved scan paths for one browser
+type BrowserPaths struct {
+	Browser     string      `json:"browser"`
+	Unsupported bool        `json:"unsupported,omitempty"`
+	Error       string      `json:"error,omitempty"`
+	Paths       []PathCheck `json:"paths"`
+}
+
+// Values of DoctorCheck.Status
+const (
+	CheckPass = "pass"
+	CheckFail = "fail"
+	CheckSkip = "skip" // Not applicable, such as a browser that isn't installed
+)
+
+// DoctorCheck is one line of the -doctor checklist
+type DoctorCheck struct {
+	Browser string `json:"browser,omitempty"` // Empty for checks not tied to a browser
+	Label   string `json:"label"`
+	Path    string `json:"path,omitempty"`
+	Status  string `json:"status"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// BrowserStatus distinguishes a browser that isn't installed from one that is
+// installed but has no profile data yet
+type BrowserStatus struct {
+	Browser     string `json:"browser" toml:"browser"`
+	Installed   bool   `json:"installed" toml:"installed"`
+	InstallPath string `json:"install_path,omitempty" toml:"install_path,omitempty"`
+	HasProfile  bool   `json:"has_profile" toml:"has_profile"`
 }
 
 // InventoryOutput struct for JSON output
@@ -0,0 +1,71 @@
+package browsers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// UserHome is a local user's home directory
+type UserHome struct {
+	User string `json:"user"`
+	Path string `json:"path"`
+}
+
+// nonUserHomes are entries under the homes root that aren't real accounts
+var nonUserHomes = map[string]bool{
+	"All Users":    true, // Windows
+	"Default":      true,
+	"Default User": true,
+	"Public":       true,
+	"Shared":       true, // macOS
+	"lost+found":   true, // Linux
+}
+
+// homesRoot returns the directory holding user home directories on this OS
+func homesRoot() (string, bool) {
+	switch runtime.GOOS {
+	case "windows":
+		drive := os.Getenv("SystemDrive")
+		if drive == "" {
+			drive = "C:"
+		}
+		return drive + `\Users`, true
+	case "darwin":
+		return "/Users", true
+	case "linux":
+		return "/home", true
+	default:
+		return "", false
+	}
+}
+
+// UserHomes lists the home directories under /home, /Users, or C:\Users,
+// named after their directories. Homes that can't be read are skipped with a
+// warning on stderr, since scanning other users usually requires elevated
+// permissions.
+func UserHomes() ([]UserHome, error) {
+	root, ok := homesRoot()
+	if !ok {
+		return nil, errUnsupportedOS
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", root, err)
+	}
+
+	var homes []UserHome
+	for _, entry := range entries {
+		if nonUserHomes[entry.Name()] || !isDirEntry(root, entry) {
+			continue
+		}
+		path := filepath.Join(root, entry.Name())
+		if _, err := os.ReadDir(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Skipping user %s: %v\n", entry.Name(), err)
+			continue
+		}
+		homes = append(homes, UserHome{User: entry.Name(), Path: path})
+	}
+	return homes, nil
+}
@@ -0,0 +1,256 @@
+package browsers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Chromium content verification files, relative to an extension's version
+// directory. verified_contents.json comes signed from the Web Store;
+// computed_hashes.json is written by the browser on first load.
+const (
+	verifiedContentsFile = "_metadata/verified_contents.json"
+	computedHashesFile   = "_metadata/computed_hashes.json"
+)
+
+// Block sizes assumed when the hash files don't record them
+const (
+	contentBlockSize     = 4096
+	contentHashBlockSize = 4096
+)
+
+// contentHashes are the expected hashes for one extension's files, keyed by
+// lower-cased slash-separated path as Chromium matches them
+type contentHashes struct {
+	files         map[string][]byte
+	blockSize     int
+	hashBlockSize int
+	// treeHash is set for verified_contents.json, whose hashes are tree hash
+	// roots; computed_hashes.json hashes are concatenated block hashes
+	treeHash bool
+	// paths holds the original spelling of each key, for reporting
+	paths map[string]string
+}
+
+// verifyChromiumContents checks an extension's files against the hashes
+// Chromium keeps in _metadata. It returns nil when the extension has no
+// hashes (unpacked and off-store extensions), otherwise whether every listed
+// file matched and the paths of those that didn't. A listed file that is
+// missing counts as modified.
+func verifyChromiumContents(dir string) (*bool, []string, error) {
+	hashes, err := loadContentHashes(dir)
+	if err != nil || hashes == nil {
+		return nil, nil, err
+	}
+
+	keys := make([]string, 0, len(hashes.files))
+	for key := range hashes.files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var modified []string
+	for _, key := range keys {
+		path := hashes.paths[key]
+		// The hash files list paths inside the extension; anything else
+		// can't be checked and is treated as a mismatch
+		if !filepath.IsLocal(filepath.FromSlash(path)) {
+			modified = append(modified, path)
+			continue
+		}
+		got, err := hashes.hashFile(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil || !bytes.Equal(got, hashes.files[key]) {
+			modified = append(modified, path)
+		}
+	}
+	verified := len(modified) == 0
+	return &verified, modified, nil
+}
+
+// loadContentHashes reads verified_contents.json, falling back to
+// computed_hashes.json. It returns nil when neither exists.
+func loadContentHashes(dir string) (*contentHashes, error) {
+	hashes, err := loadVerifiedContents(filepath.Join(dir, filepath.FromSlash(verifiedContentsFile)))
+	if err == nil || !os.IsNotExist(err) {
+		return hashes, err
+	}
+	hashes, err = loadComputedHashes(filepath.Join(dir, filepath.FromSlash(computedHashesFile)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return hashes, err
+}
+
+// loadVerifiedContents reads the tree hash roots from the signed payload of
+// verified_contents.json. The signature itself isn't checked.
+func loadVerifiedContents(path string) (*contentHashes, error) {
+	var contents []struct {
+		SignedContent struct {
+			Payload string `json:"payload"`
+		} `json:"signed_content"`
+	}
+	if err := readJSONFile(path, &contents); err != nil {
+		return nil, err
+	}
+
+	hashes := &contentHashes{
+		files:         make(map[string][]byte),
+		paths:         make(map[string]string),
+		blockSize:     contentBlockSize,
+		hashBlockSize: contentHashBlockSize,
+		treeHash:      true,
+	}
+	for _, content := range contents {
+		payload, err := decodeBase64URL(content.SignedContent.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode payload in %s: %w", path, err)
+		}
+		var signed struct {
+			ContentHashes []struct {
+				Format        string `json:"format"`
+				BlockSize     int    `json:"block_size"`
+				HashBlockSize int    `json:"hash_block_size"`
+				Files         []struct {
+					Path     string `json:"path"`
+					RootHash string `json:"root_hash"`
+				} `json:"files"`
+			} `json:"content_hashes"`
+		}
+		if err := json.Unmarshal(payload, &signed); err != nil {
+			return nil, fmt.Errorf("failed to parse payload in %s: %w", path, err)
+		}
+		for _, set := range signed.ContentHashes {
+			if set.Format != "treehash" {
+				continue
+			}
+			if set.BlockSize > 0 {
+				hashes.blockSize = set.BlockSize
+			}
+			if set.HashBlockSize > 0 {
+				hashes.hashBlockSize = set.HashBlockSize
+			}
+			for _, file := range set.Files {
+				root, err := decodeBase64URL(file.RootHash)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode hash for %s in %s: %w", file.Path, path, err)
+				}
+				hashes.add(file.Path, root)
+			}
+		}
+	}
+	if len(hashes.files) == 0 {
+		return nil, fmt.Errorf("no treehash entries in %s", path)
+	}
+	return hashes, nil
+}
+
+// loadComputedHashes reads the per-block hashes from computed_hashes.json
+func loadComputedHashes(path string) (*contentHashes, error) {
+	var computed struct {
+		FileHashes []struct {
+			Path        string   `json:"path"`
+			BlockSize   int      `json:"block_size"`
+			BlockHashes []string `json:"block_hashes"`
+		} `json:"file_hashes"`
+	}
+	if err := readJSONFile(path, &computed); err != nil {
+		return nil, err
+	}
+
+	hashes := &contentHashes{
+		files:     make(map[string][]byte),
+		paths:     make(map[string]string),
+		blockSize: contentBlockSize,
+	}
+	for _, file := range computed.FileHashes {
+		if file.BlockSize > 0 {
+			hashes.blockSize = file.BlockSize
+		}
+		var joined []byte
+		for _, block := range file.BlockHashes {
+			sum, err := base64.StdEncoding.DecodeString(block)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode hash for %s in %s: %w", file.Path, path, err)
+			}
+			joined = append(joined, sum...)
+		}
+		hashes.add(file.Path, joined)
+	}
+	if len(hashes.files) == 0 {
+		return nil, fmt.Errorf("no file hashes in %s", path)
+	}
+	return hashes, nil
+}
+
+// add records the expected hash for path
+func (h *contentHashes) add(path string, sum []byte) {
+	key := strings.ToLower(path)
+	h.files[key] = sum
+	h.paths[key] = path
+}
+
+// hashFile hashes the file at path the way the expected hashes were made:
+// the SHA-256 of each block, reduced to a tree hash root for
+// verified_contents.json or concatenated for computed_hashes.json
+func (h *contentHashes) hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var leaves [][]byte
+	block := make([]byte, h.blockSize)
+	for {
+		n, err := io.ReadFull(f, block)
+		if n > 0 || len(leaves) == 0 && errors.Is(err, io.EOF) {
+			// An empty file still has one block, the hash of nothing
+			sum := sha256.Sum256(block[:n])
+			leaves = append(leaves, sum[:])
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !h.treeHash {
+		return bytes.Join(leaves, nil), nil
+	}
+	return treeHashRoot(leaves, h.hashBlockSize/sha256.Size), nil
+}
+
+// treeHashRoot combines block hashes into Chromium's tree hash root: each
+// level hashes runs of branch hashes from the level below until one is left
+func treeHashRoot(level [][]byte, branch int) []byte {
+	if branch < 2 {
+		branch = 2
+	}
+	for len(level) > 1 {
+		var next [][]byte
+		for i := 0; i < len(level); i += branch {
+			end := min(i+branch, len(level))
+			sum := sha256.Sum256(bytes.Join(level[i:end], nil))
+			next = append(next, sum[:])
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// decodeBase64URL decodes URL-safe base64 with or without padding, as found
+// in verified_contents.json
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
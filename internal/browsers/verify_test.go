@@ -0,0 +1,106 @@
+package browsers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"path/filepath"
+	"testing"
+)
+
+func sha(parts ...[]byte) []byte {
+	sum := sha256.Sum256(bytes.Join(parts, nil))
+	return sum[:]
+}
+
+func TestTreeHashRoot(t *testing.T) {
+	a, b, c := sha([]byte("a")), sha([]byte("b")), sha([]byte("c"))
+	tests := []struct {
+		name   string
+		leaves [][]byte
+		branch int
+		want   []byte
+	}{
+		{name: "one leaf is its own root", leaves: [][]byte{a}, branch: 128, want: a},
+		{name: "one full branch", leaves: [][]byte{a, b}, branch: 2, want: sha(a, b)},
+		{name: "partial branch", leaves: [][]byte{a, b, c}, branch: 2, want: sha(sha(a, b), sha(c))},
+		{name: "wide branch", leaves: [][]byte{a, b, c}, branch: 128, want: sha(a, b, c)},
+		{name: "branch below 2 is raised", leaves: [][]byte{a, b, c}, branch: 1, want: sha(sha(a, b), sha(c))},
+		{name: "three levels", leaves: [][]byte{a, b, c, a, b}, branch: 2, want: sha(sha(sha(a, b), sha(c, a)), sha(sha(b)))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := treeHashRoot(tt.leaves, tt.branch); !bytes.Equal(got, tt.want) {
+				t.Errorf("treeHashRoot() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	dir := t.TempDir()
+	full := filepath.Join(dir, "full.js")
+	writeFile(t, full, "aaaabbbbcc")
+	empty := filepath.Join(dir, "empty.js")
+	writeFile(t, empty, "")
+	aaaa, bbbb, cc := sha([]byte("aaaa")), sha([]byte("bbbb")), sha([]byte("cc"))
+
+	tests := []struct {
+		name   string
+		hashes contentHashes
+		path   string
+		want   []byte
+	}{
+		{name: "computed", hashes: contentHashes{blockSize: 4}, path: full, want: bytes.Join([][]byte{aaaa, bbbb, cc}, nil)},
+		{name: "computed, one block", hashes: contentHashes{blockSize: 4096}, path: full, want: sha([]byte("aaaabbbbcc"))},
+		{name: "computed, empty", hashes: contentHashes{blockSize: 4}, path: empty, want: sha()},
+		// A hash block of 64 bytes holds two SHA-256 hashes, so the tree
+		// branches in twos
+		{name: "tree", hashes: contentHashes{blockSize: 4, hashBlockSize: 64, treeHash: true}, path: full, want: sha(sha(aaaa, bbbb), sha(cc))},
+		{name: "tree, empty", hashes: contentHashes{blockSize: 4, hashBlockSize: 64, treeHash: true}, path: empty, want: sha()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.hashes.hashFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("hashFile() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+
+	h := contentHashes{blockSize: 4}
+	if _, err := h.hashFile(filepath.Join(dir, "missing.js")); err == nil {
+		t.Error("hashFile succeeded for a missing file")
+	}
+}
+
+// TestVerifyChromiumContents checks files against a computed_hashes.json,
+// then modifies one
+func TestVerifyChromiumContents(t *testing.T) {
+	dir := t.TempDir()
+	if verified, _, err := verifyChromiumContents(dir); verified != nil || err != nil {
+		t.Errorf("without _metadata: verified = %v, err = %v; want nothing reported", verified, err)
+	}
+
+	writeFile(t, filepath.Join(dir, "background.js"), "console.log(1)")
+	writeFile(t, filepath.Join(dir, "js", "content.js"), "")
+	hash := func(s string) string { return base64.StdEncoding.EncodeToString(sha([]byte(s))) }
+	writeFile(t, filepath.Join(dir, filepath.FromSlash(computedHashesFile)), `{"file_hashes": [
+		{"path": "background.js", "block_size": 4096, "block_hashes": ["`+hash("console.log(1)")+`"]},
+		{"path": "js/content.js", "block_size": 4096, "block_hashes": ["`+hash("")+`"]}
+	]}`)
+
+	verified, modified, err := verifyChromiumContents(dir)
+	if err != nil || verified == nil || !*verified || len(modified) != 0 {
+		t.Fatalf("verifyChromiumContents = %v, %q, %v; want verified", verified, modified, err)
+	}
+
+	writeFile(t, filepath.Join(dir, "background.js"), "console.log(2)")
+	verified, modified, err = verifyChromiumContents(dir)
+	if err != nil || verified == nil || *verified || len(modified) != 1 || modified[0] != "background.js" {
+		t.Errorf("after modifying background.js: verifyChromiumContents = %v, %q, %v", verified, modified, err)
+	}
+}
@@ -0,0 +1,77 @@
+package exclude
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go-browser-inventory/internal/browsers"
+)
+
+//go:embed default.txt
+var defaultList string
+
+// Set is a set of extension IDs left out of every report
+type Set map[string]bool
+
+// Default returns the built-in list of component and system extension IDs
+// that appear on every machine
+func Default() Set {
+	set, err := Parse(strings.NewReader(defaultList))
+	if err != nil {
+		panic(fmt.Sprintf("invalid default exclusion list: %v", err))
+	}
+	return set
+}
+
+// Load reads an exclusion file in the format described by Parse
+func Load(path string) (Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open exclusion file: %w", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads a list of extension IDs: one per line, with blank lines and
+// lines starting with '#' ignored. Trailing "# comments" are allowed. Policy
+// files share the format.
+func Parse(r io.Reader) (Set, error) {
+	set := make(Set)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if id := strings.TrimSpace(line); id != "" {
+			set[id] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ID list: %w", err)
+	}
+	return set, nil
+}
+
+// Add adds every ID in other to s
+func (s Set) Add(other Set) {
+	for id := range other {
+		s[id] = true
+	}
+}
+
+// Filter drops the extensions whose ID is in s
+func (s Set) Filter(extensions []browsers.Extension) []browsers.Extension {
+	var filtered []browsers.Extension
+	for _, ext := range extensions {
+		if !s[ext.ID] {
+			filtered = append(filtered, ext)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,65 @@
+package exclude
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-browser-inventory/internal/browsers"
+)
+
+func TestParse(t *testing.T) {
+	input := `# Extensions every machine has
+aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
+
+  bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb  # indented, with a trailing comment
+cccccccccccccccccccccccccccccccc#no space before the comment
+	# an indented comment
+aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
+`
+	got, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Set{
+		"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa": true,
+		"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb": true,
+		"cccccccccccccccccccccccccccccccc": true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %v, want %v", got, want)
+	}
+
+	if got, err := Parse(strings.NewReader("")); err != nil || len(got) != 0 {
+		t.Errorf("Parse(empty) = %v, %v; want an empty set", got, err)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	if len(Default()) == 0 {
+		t.Error("the default exclusion list is empty")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	extensions := []browsers.Extension{
+		{ID: "keep1", Profile: "Default"},
+		{ID: "drop", Profile: "Default"},
+		{ID: "keep2", Profile: "Work"},
+		{ID: "drop", Profile: "Work"},
+	}
+	set := Set{"drop": true}
+	set.Add(Set{"missing": true})
+
+	var ids []string
+	for _, ext := range set.Filter(extensions) {
+		ids = append(ids, ext.ID+"/"+ext.Profile)
+	}
+	if want := []string{"keep1/Default", "keep2/Work"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Filter() kept %q, want %q", ids, want)
+	}
+
+	if got := (Set{}).Filter(extensions); len(got) != len(extensions) {
+		t.Errorf("an empty set filtered %d of %d extensions", len(extensions)-len(got), len(extensions))
+	}
+}
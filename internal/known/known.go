@@ -0,0 +1,123 @@
+package known
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go-browser-inventory/internal/browsers"
+)
+
+// Status values assigned to scanned extensions
+const (
+	StatusApproved = "approved"
+	StatusBlocked  = "blocked"
+	StatusUnknown  = "unknown"
+)
+
+// RiskLevels are the recognized risk ratings, lowest first. Ratings outside
+// this scale are kept as-is but can't be compared.
+var RiskLevels = []string{"low", "medium", "high", "critical"}
+
+// RiskRank returns the position of risk in RiskLevels (case-insensitive)
+func RiskRank(risk string) (int, bool) {
+	for i, level := range RiskLevels {
+		if strings.EqualFold(strings.TrimSpace(risk), level) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Entry is a single known extension from the catalog file
+type Entry struct {
+	ID     string
+	Name   string
+	Status string
+	Risk   string
+}
+
+// Catalog maps extension IDs to their known entries
+type Catalog map[string]Entry
+
+// Load reads a known-extensions CSV file.
+//
+// The file has the columns id,name,status,risk. Only id is required; status
+// is "approved" or "blocked" and defaults to "approved" when empty. An optional
+// header row starting with "id" is skipped, as are lines beginning with '#'.
+func Load(path string) (Catalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open known extensions file: %w", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads known extensions in the CSV format described by Load
+func Parse(r io.Reader) (Catalog, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	catalog := make(Catalog)
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse known extensions: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		id := strings.TrimSpace(record[0])
+		if first && strings.EqualFold(id, "id") {
+			continue // Header row
+		}
+		if id == "" && len(record) == 1 {
+			continue // A blank line holding only spaces
+		}
+		if id == "" {
+			return nil, fmt.Errorf("line %d: missing extension id", line)
+		}
+
+		entry := Entry{ID: id, Status: StatusApproved}
+		if len(record) > 1 {
+			entry.Name = strings.TrimSpace(record[1])
+		}
+		if len(record) > 2 {
+			switch status := strings.ToLower(strings.TrimSpace(record[2])); status {
+			case "", StatusApproved:
+			case StatusBlocked:
+				entry.Status = StatusBlocked
+			default:
+				return nil, fmt.Errorf("line %d: invalid status %q (want %s or %s)", line, status, StatusApproved, StatusBlocked)
+			}
+		}
+		if len(record) > 3 {
+			entry.Risk = strings.TrimSpace(record[3])
+		}
+		catalog[id] = entry
+	}
+	return catalog, nil
+}
+
+// Apply joins scanned extensions against the catalog, setting each one's known
+// status, risk, and friendly name
+func (c Catalog) Apply(extensions []browsers.Extension) {
+	for i := range extensions {
+		entry, ok := c[extensions[i].ID]
+		if !ok {
+			extensions[i].KnownStatus = StatusUnknown
+			continue
+		}
+		extensions[i].KnownStatus = entry.Status
+		extensions[i].Risk = entry.Risk
+		extensions[i].FriendlyName = entry.Name
+	}
+}
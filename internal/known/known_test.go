@@ -0,0 +1,87 @@
+package known
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	const idA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const idB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	tests := []struct {
+		name    string
+		input   string
+		want    Catalog
+		wantErr string
+	}{
+		{
+			name:  "full rows with header",
+			input: "id,name,status,risk\n" + idA + ",Password Manager,approved,low\n" + idB + ",Coupon Finder,Blocked,high\n",
+			want: Catalog{
+				idA: {ID: idA, Name: "Password Manager", Status: StatusApproved, Risk: "low"},
+				idB: {ID: idB, Name: "Coupon Finder", Status: StatusBlocked, Risk: "high"},
+			},
+		},
+		{
+			name:  "id only defaults to approved",
+			input: idA + "\n",
+			want:  Catalog{idA: {ID: idA, Status: StatusApproved}},
+		},
+		{
+			name:  "empty status defaults to approved",
+			input: idA + ", Name ,,medium\n",
+			want:  Catalog{idA: {ID: idA, Name: "Name", Status: StatusApproved, Risk: "medium"}},
+		},
+		{
+			name:  "comments",
+			input: "# Approved by IT, 2024\n" + idA + ",Name\n#" + idB + ",Disabled entry\n",
+			want:  Catalog{idA: {ID: idA, Name: "Name", Status: StatusApproved}},
+		},
+		{
+			name:  "blank lines",
+			input: "\n" + idA + "\n\n  \n\t\n" + idB + ",,blocked\n\n",
+			want: Catalog{
+				idA: {ID: idA, Status: StatusApproved},
+				idB: {ID: idB, Status: StatusBlocked},
+			},
+		},
+		{
+			name:  "empty file",
+			input: "",
+			want:  Catalog{},
+		},
+		{
+			name:    "missing id",
+			input:   idA + "\n,Nameless\n",
+			wantErr: "line 2: missing extension id",
+		},
+		{
+			name:    "invalid status",
+			input:   idA + ",Name,maybe\n",
+			wantErr: `line 1: invalid status "maybe"`,
+		},
+		{
+			name:    "unterminated quote",
+			input:   idA + ",\"Name\n",
+			wantErr: "failed to parse known extensions",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
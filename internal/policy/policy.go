@@ -0,0 +1,54 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+
+	"go-browser-inventory/internal/browsers"
+	"go-browser-inventory/internal/exclude"
+)
+
+// Policy modes
+const (
+	ModeAllow = "allow" // Only listed IDs may be installed
+	ModeDeny  = "deny"  // Listed IDs must not be installed
+)
+
+// Policy is a set of extension IDs evaluated in allow or deny mode
+type Policy struct {
+	Mode string
+	IDs  map[string]bool
+}
+
+// Load reads a policy file, which lists IDs in the same format as an
+// exclusion file (see exclude.Parse)
+func Load(path, mode string) (*Policy, error) {
+	if mode != ModeAllow && mode != ModeDeny {
+		return nil, fmt.Errorf("invalid policy mode %q (want %s or %s)", mode, ModeAllow, ModeDeny)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open policy file: %w", err)
+	}
+	defer f.Close()
+
+	ids, err := exclude.Parse(f)
+	if err != nil {
+		return nil, err
+	}
+	return &Policy{Mode: mode, IDs: ids}, nil
+}
+
+// Violations returns the extensions that break the policy: those missing from
+// an allowlist, or present on a denylist
+func (p *Policy) Violations(extensions []browsers.Extension) []browsers.Extension {
+	var violations []browsers.Extension
+	for _, ext := range extensions {
+		listed := p.IDs[ext.ID]
+		if (p.Mode == ModeAllow && !listed) || (p.Mode == ModeDeny && listed) {
+			violations = append(violations, ext)
+		}
+	}
+	return violations
+}
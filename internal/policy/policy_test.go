@@ -0,0 +1,100 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go-browser-inventory/internal/browsers"
+)
+
+// writePolicy writes contents to a policy file in a temporary directory
+func writePolicy(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.txt")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writePolicy(t, `# Approved extensions
+
+aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
+bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb   # password manager
+   # cccccccccccccccccccccccccccccccc was retired
+`)
+	tests := []struct {
+		mode    string
+		wantErr bool
+	}{
+		{mode: ModeAllow},
+		{mode: ModeDeny},
+		{mode: "block", wantErr: true},
+		{mode: "", wantErr: true},
+		{mode: "Allow", wantErr: true},
+	}
+	for _, tt := range tests {
+		p, err := Load(path, tt.mode)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Load(%q) succeeded, want an invalid mode error", tt.mode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Load(%q): %v", tt.mode, err)
+			continue
+		}
+		want := map[string]bool{
+			"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa": true,
+			"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb": true,
+		}
+		if p.Mode != tt.mode || !reflect.DeepEqual(p.IDs, want) {
+			t.Errorf("Load(%q) = %+v, want mode %q and IDs %v", tt.mode, p, tt.mode, want)
+		}
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.txt"), ModeAllow); err == nil {
+		t.Error("Load succeeded for a missing file")
+	}
+}
+
+func TestViolations(t *testing.T) {
+	extensions := []browsers.Extension{
+		{ID: "approved", Profile: "Default"},
+		{ID: "unknown", Profile: "Default"},
+		{ID: "approved", Profile: "Work"},
+		{ID: "banned", Profile: "Work"},
+	}
+	tests := []struct {
+		name string
+		mode string
+		ids  []string
+		want []string
+	}{
+		{name: "allow", mode: ModeAllow, ids: []string{"approved"}, want: []string{"unknown/Default", "banned/Work"}},
+		{name: "allow everything installed", mode: ModeAllow, ids: []string{"approved", "unknown", "banned"}},
+		{name: "empty allowlist", mode: ModeAllow, want: []string{"approved/Default", "unknown/Default", "approved/Work", "banned/Work"}},
+		{name: "deny", mode: ModeDeny, ids: []string{"banned", "not-installed"}, want: []string{"banned/Work"}},
+		{name: "deny every profile", mode: ModeDeny, ids: []string{"approved"}, want: []string{"approved/Default", "approved/Work"}},
+		{name: "empty denylist", mode: ModeDeny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{Mode: tt.mode, IDs: make(map[string]bool)}
+			for _, id := range tt.ids {
+				p.IDs[id] = true
+			}
+			var got []string
+			for _, ext := range p.Violations(extensions) {
+				got = append(got, ext.ID+"/"+ext.Profile)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Violations() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
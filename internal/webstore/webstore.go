@@ -0,0 +1,137 @@
+package webstore
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Update service endpoints for the stores backing Chromium browsers
+const (
+	ChromeUpdateURL = "https://clients2.google.com/service/update2/crx"
+	EdgeUpdateURL   = "https://edge.microsoft.com/extensionwebstorebase/v1/crx"
+)
+
+// Store status values
+const (
+	StatusListed  = "listed"  // The store serves the extension
+	StatusRemoved = "removed" // The store no longer knows the extension
+)
+
+// prodVersion is the browser version reported to the update service
+const prodVersion = "130.0.0.0"
+
+// Listing is the store's view of an extension. The update service only
+// reports versions, so the listing's name and rating aren't available; those
+// are on the store's web pages, which have no stable API.
+type Listing struct {
+	ID            string
+	LatestVersion string
+	Status        string
+}
+
+// Client queries a store's update service for extension listings
+type Client struct {
+	HTTPClient  *http.Client
+	Concurrency int
+}
+
+// NewClient creates a client with the given concurrency and per-request timeout
+func NewClient(concurrency int, timeout time.Duration) *Client {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Client{
+		HTTPClient:  &http.Client{Timeout: timeout},
+		Concurrency: concurrency,
+	}
+}
+
+// Lookup fetches listings for the given IDs from the store at updateURL.
+// Listings that could be fetched are returned even if others failed; the
+// failures are joined into the returned error.
+func (c *Client) Lookup(ctx context.Context, updateURL string, ids []string) (map[string]Listing, error) {
+	listings := make(map[string]Listing)
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	sem := make(chan struct{}, c.Concurrency)
+
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			listing, err := c.lookupOne(ctx, updateURL, id)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("lookup %s: %w", id, err))
+				return
+			}
+			listings[id] = listing
+		}(id)
+	}
+	wg.Wait()
+
+	return listings, errors.Join(errs...)
+}
+
+// updateResponse mirrors the Omaha v2 update check response
+type updateResponse struct {
+	Apps []struct {
+		AppID       string `xml:"appid,attr"`
+		Status      string `xml:"status,attr"`
+		UpdateCheck struct {
+			Status  string `xml:"status,attr"`
+			Version string `xml:"version,attr"`
+		} `xml:"updatecheck"`
+	} `xml:"app"`
+}
+
+// lookupOne performs a single update check for an extension ID
+func (c *Client) lookupOne(ctx context.Context, updateURL, id string) (Listing, error) {
+	query := url.Values{}
+	query.Set("response", "updatecheck")
+	query.Set("acceptformat", "crx3")
+	query.Set("prodversion", prodVersion)
+	query.Set("x", "id="+id+"&uc")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, updateURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return Listing{}, fmt.Errorf("failed to build request: %w", err)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return Listing{}, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Listing{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var parsed updateResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Listing{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(parsed.Apps) == 0 {
+		return Listing{}, fmt.Errorf("response contained no app entry")
+	}
+
+	app := parsed.Apps[0]
+	listing := Listing{ID: id, Status: StatusRemoved}
+	if app.Status == "ok" && app.UpdateCheck.Status == "ok" && app.UpdateCheck.Version != "" {
+		listing.Status = StatusListed
+		listing.LatestVersion = app.UpdateCheck.Version
+	}
+	return listing, nil
+}
@@ -0,0 +1,133 @@
+package webstore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestStore serves update checks, answering each extension ID with its
+// entry in responses
+func newTestStore(t *testing.T, responses map[string]func(w http.ResponseWriter)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		x, err := url.ParseQuery(r.URL.Query().Get("x"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		respond, ok := responses[x.Get("id")]
+		if !ok {
+			http.Error(w, "unexpected id "+x.Get("id"), http.StatusBadRequest)
+			return
+		}
+		respond(w)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// reply writes an update check response with the given status and body
+func reply(status int, body string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	const (
+		listedID   = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+		noupdateID = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+		errorID    = "cccccccccccccccccccccccccccccccc"
+		garbageID  = "dddddddddddddddddddddddddddddddd"
+		emptyID    = "eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+	)
+	srv := newTestStore(t, map[string]func(w http.ResponseWriter){
+		listedID: reply(http.StatusOK, `<?xml version="1.0" encoding="UTF-8"?>
+<gupdate xmlns="http://www.google.com/update2/response" protocol="2.0">
+  <app appid="`+listedID+`" status="ok">
+    <updatecheck codebase="https://example.com/ext.crx" status="ok" version="4.2.1"/>
+  </app>
+</gupdate>`),
+		noupdateID: reply(http.StatusOK, `<?xml version="1.0" encoding="UTF-8"?>
+<gupdate xmlns="http://www.google.com/update2/response" protocol="2.0">
+  <app appid="`+noupdateID+`" status="ok">
+    <updatecheck status="noupdate"/>
+  </app>
+</gupdate>`),
+		errorID:   reply(http.StatusInternalServerError, "server error"),
+		garbageID: reply(http.StatusOK, "<gupdate><app"),
+		emptyID:   reply(http.StatusOK, `<gupdate protocol="2.0"></gupdate>`),
+	})
+
+	client := NewClient(2, 5*time.Second)
+	listings, err := client.Lookup(context.Background(), srv.URL, []string{listedID, noupdateID, errorID, garbageID, emptyID})
+
+	want := map[string]Listing{
+		listedID:   {ID: listedID, Status: StatusListed, LatestVersion: "4.2.1"},
+		noupdateID: {ID: noupdateID, Status: StatusRemoved},
+	}
+	if len(listings) != len(want) {
+		t.Errorf("Lookup returned %d listings, want %d: %+v", len(listings), len(want), listings)
+	}
+	for id, w := range want {
+		if got := listings[id]; got != w {
+			t.Errorf("listing for %s = %+v, want %+v", id, got, w)
+		}
+	}
+
+	// Failed lookups are reported, not turned into removed listings
+	if err == nil {
+		t.Fatal("Lookup returned no error for the failed lookups")
+	}
+	for _, id := range []string{errorID, garbageID, emptyID} {
+		if !strings.Contains(err.Error(), id) {
+			t.Errorf("error %q doesn't mention %s", err, id)
+		}
+	}
+}
+
+func TestLookupRequest(t *testing.T) {
+	const id = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		fmt.Fprint(w, `<gupdate><app appid="`+id+`" status="ok"><updatecheck status="ok" version="1.0"/></app></gupdate>`)
+	}))
+	defer srv.Close()
+
+	if _, err := NewClient(1, 5*time.Second).Lookup(context.Background(), srv.URL, []string{id}); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		"response":     "updatecheck",
+		"acceptformat": "crx3",
+		"prodversion":  prodVersion,
+		"x":            "id=" + id + "&uc",
+	} {
+		if got := query.Get(key); got != want {
+			t.Errorf("query %s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestLookupTimeout(t *testing.T) {
+	const id = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	listings, err := NewClient(1, 50*time.Millisecond).Lookup(context.Background(), srv.URL, []string{id})
+	if err == nil || len(listings) != 0 {
+		t.Errorf("Lookup = %+v, %v; want no listings and a timeout error", listings, err)
+	}
+}
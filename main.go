@@ -1,118 +1,1269 @@
 package main
 
 import (
-	"encoding/json"
+	"bufio"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
 
 	"go-browser-inventory/db"
 	"go-browser-inventory/internal/browsers"
+	"go-browser-inventory/internal/exclude"
+	"go-browser-inventory/internal/known"
+	"go-browser-inventory/internal/policy"
+	"go-browser-inventory/internal/webstore"
 )
 
+// Exit codes returned by the tool so automation can branch on the outcome
+const (
+	exitOK             = 0 // Success with at least one extension reported
+	exitError          = 1 // Fatal error (DB initialization, output encoding)
+	exitNoExtensions   = 2 // Scan succeeded but no extensions were found
+	exitAllFailed      = 3 // Every selected browser failed to scan
+	exitPartialFailure = 4 // Some browsers failed, others succeeded
+	exitNotFound       = 5 // The extension requested with -get wasn't found
+	exitPolicyFailed   = 6 // An installed extension violates -policy-file
+	exitThreshold      = 7 // -max-extensions or -max-risk was exceeded
+	exitNoBrowsers     = 8 // The browser selection matched no configured browser
+)
+
+// dbPath is the cache database, relative to the working directory
+const dbPath = "./browser_inventory.db"
+
+// version is the tool version, overridden at build time with
+// -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// Values of scanMeta.Source
+const (
+	sourceCache = "cache" // Every browser was served from the DB cache
+	sourceFresh = "fresh" // Every browser was scanned this run
+	sourceMixed = "mixed" // Some browsers came from cache, others were scanned
+)
+
+// scanMeta describes when, where, and how an inventory was produced
+type scanMeta struct {
+	Hostname    string            `json:"hostname" toml:"hostname"`
+	OS          string            `json:"os" toml:"os"`
+	Timestamp   string            `json:"timestamp" toml:"timestamp"`
+	ToolVersion string            `json:"tool_version" toml:"tool_version"`
+	Source      string            `json:"source" toml:"source"`
+	Browsers    []browserDataMeta `json:"browsers,omitempty" toml:"browsers,omitempty"`
+}
+
+// browserDataMeta records where one browser's results came from, when that
+// data was collected, and how old it was when reported, so an inventory
+// mixing a fresh -update-cache of one browser with older cached data for the
+// others says so
+type browserDataMeta struct {
+	Browser    string `json:"browser" toml:"browser"`
+	Source     string `json:"source" toml:"source"`
+	UpdatedAt  string `json:"updated_at" toml:"updated_at"`
+	AgeSeconds int64  `json:"age_seconds" toml:"age_seconds"`
+	// ProfilesScanned is how many profiles the scan read; unknown for caches
+	// written before it was recorded
+	ProfilesScanned *int `json:"profiles_scanned,omitempty" toml:"profiles_scanned,omitempty"`
+}
+
 type output struct {
+	Extensions  []browsers.Extension     `json:"extensions" toml:"extensions"`
+	Total       int                      `json:"total" toml:"total"`
+	UniqueTotal int                      `json:"unique_total" toml:"unique_total"`
+	Browsers    []browsers.BrowserStatus `json:"browsers,omitempty" toml:"browsers,omitempty"`
+	Meta        *scanMeta                `json:"meta,omitempty" toml:"meta,omitempty"`
+	Errors      []browsers.FileError     `json:"errors,omitempty" toml:"errors,omitempty"`
+	// Skipped lists what the scan couldn't enter, such as directories it
+	// isn't permitted to read; set with -report-errors
+	Skipped []browsers.SkippedPath `json:"skipped,omitempty" toml:"skipped,omitempty"`
+	// KeyConflicts is set with -verify-ids
+	KeyConflicts []browsers.KeyConflict `json:"key_conflicts,omitempty" toml:"key_conflicts,omitempty"`
+}
+
+// groupedOutput is the -json -group shape: extensions nested by browser, then
+// profile, with totals at each level. The remaining fields match output.
+type groupedOutput struct {
+	Groups       map[string]browserGroup  `json:"groups"`
+	Total        int                      `json:"total"`
+	UniqueTotal  int                      `json:"unique_total"`
+	Browsers     []browsers.BrowserStatus `json:"browsers,omitempty"`
+	Meta         *scanMeta                `json:"meta,omitempty"`
+	Errors       []browsers.FileError     `json:"errors,omitempty"`
+	Skipped      []browsers.SkippedPath   `json:"skipped,omitempty"`
+	KeyConflicts []browsers.KeyConflict   `json:"key_conflicts,omitempty"`
+}
+
+// browserGroup is one browser's extensions keyed by profile name, prefixed
+// with "user/" when scanning with -all-users
+type browserGroup struct {
+	Total    int                     `json:"total"`
+	Profiles map[string]profileGroup `json:"profiles"`
+}
+
+// profileGroup is one profile's extensions, in scan order
+type profileGroup struct {
+	Total      int                  `json:"total"`
 	Extensions []browsers.Extension `json:"extensions"`
+}
+
+type permissionSummaryOutput struct {
+	Permissions []browsers.PermissionCount `json:"permissions"`
+	Total       int                        `json:"total"`
+}
+
+type countOutput struct {
+	Total int `json:"total"`
+}
+
+type duplicatesOutput struct {
+	Duplicates []browsers.DuplicateGroup `json:"duplicates"`
+	Total      int                       `json:"total"`
+}
+
+type profilesOutput struct {
+	Profiles []browsers.Profile `json:"profiles"`
+	Total    int                `json:"total"`
+}
+
+type orphansOutput struct {
+	Orphans []browsers.Orphan `json:"orphans"`
+	Total   int               `json:"total"`
+	Size    int64             `json:"size"`
+}
+
+type compareOutput struct {
+	Hosts   []string            `json:"hosts"`
+	Common  []comparedExtension `json:"common"`
+	Partial []comparedExtension `json:"partial"`
+	Unique  []hostDelta         `json:"unique"`
+}
+
+type policyOutput struct {
+	Mode       string               `json:"mode"`
+	Compliant  bool                 `json:"compliant"`
+	Violations []browsers.Extension `json:"violations"`
 	Total      int                  `json:"total"`
 }
 
 func main() {
-	browser := flag.String("browser", "", "Browser to list extensions for (Chrome, Edge, Firefox). Leave empty for all.")
+	os.Exit(run())
+}
+
+// run executes the CLI and returns the process exit code
+func run() (code int) {
+	browser := flag.String("browser", "", "Comma-separated browsers to list extensions for (Chrome, Edge, Chromium, Yandex, Firefox), or all. Leave empty for all.")
 	jsonOutput := flag.Bool("json", false, "Output in JSON format")
+	rawManifest := flag.Bool("raw-manifest", false, "With -json, include each extension's full manifest (Firefox: its extensions.json entry) as raw_manifest (bypasses the cache)")
+	group := flag.Bool("group", false, "With -json, nest extensions by browser and profile with per-group totals")
+	relativeTime := flag.Bool("relative-time", false, "Show console timestamps as relative times such as 3 days ago")
+	noColor := flag.Bool("no-color", false, "Print console risk tags as plain text even on a terminal")
+	compact := flag.Bool("compact", false, "Emit JSON on a single line without indentation")
 	debug := flag.Bool("debug", false, "Enable debug output for troubleshooting")
 	updateCache := flag.Bool("update-cache", false, "Force update of database records, bypassing cache")
+	var warmCache bool
+	flag.BoolVar(&warmCache, "warm-cache", false, "Scan every selected browser, write the results to the cache, and exit without printing the inventory")
+	flag.BoolVar(&warmCache, "first-run", false, "Alias for -warm-cache")
+	quiet := flag.Bool("quiet", false, "Suppress confirmation messages on stderr (-warm-cache, -output, -also-output, -export)")
+	format := flag.String("format", "", "Go text/template executed per extension, e.g. '{{.Browser}}\\t{{.Name}}' (or @file)")
+	tomlOutput := flag.Bool("toml", false, "Output in TOML format")
+	prometheus := flag.Bool("prometheus", false, "Output extension counts in the Prometheus text format")
+	idsOnly := flag.Bool("ids-only", false, "Print only extension IDs, one per line, deduplicated")
+	getID := flag.String("get", "", "Show full details for the extension with this ID across browsers and profiles")
+	profileSummary := flag.Bool("profile-summary", false, "List the profiles found for each browser without scanning extensions")
+	orphans := flag.Bool("orphans", false, "Report Chromium extension directories with no readable manifest, with their sizes")
+	permissionSummary := flag.Bool("permission-summary", false, "Report how many extensions request each permission and host pattern, most requested first")
+	duplicates := flag.Bool("duplicates", false, "Report extension IDs installed in more than one browser or profile")
+	fingerprint := flag.Bool("fingerprint", false, "Print only a SHA-256 fingerprint of the inventory for change detection")
+	countOnly := flag.Bool("count-only", false, "Print only the number of extensions found, after filters")
+	ioConcurrency := flag.Int("io-concurrency", 1, "Maximum number of profiles scanned at once")
+	resume := flag.Bool("resume", false, "Checkpoint each scanned profile in the DB and resume an interrupted scan")
+	noFallbackLocale := flag.Bool("no-fallback-locale", false, "Resolve localized names only from English and the default locale, never other locales (bypasses the cache)")
+	noNameCache := flag.Bool("no-name-cache", false, "Resolve localized extension names from locale files instead of the DB name cache")
+	policyFile := flag.String("policy-file", "", "File of extension IDs (one per line) to enforce; violations exit with code 6")
+	policyMode := flag.String("policy-mode", policy.ModeAllow, "How -policy-file is applied: allow (only listed IDs permitted) or deny (listed IDs forbidden)")
+	reportErrors := flag.Bool("report-errors", false, "Include files that couldn't be read or parsed, and paths skipped for lack of permission, in JSON/TOML output")
+	tui := flag.Bool("tui", false, "Browse the results in an interactive table: type to filter, Tab to sort, Enter for details (needs a terminal)")
+	listBrowsers := flag.Bool("list-browsers", false, "Print the configured browsers with their scanner type and resolved data path on this OS, then exit")
+	doctor := flag.Bool("doctor", false, "Check the home directory, each browser's data directory and key files, and cache database access, print a pass/fail checklist, then exit (read-only)")
+	showPaths := flag.Bool("paths", false, "Print the paths that would be scanned per browser and whether they exist, then exit")
+	allUsers := flag.Bool("all-users", false, "Scan every user's home directory (under /home, /Users, or C:\\Users) and label results by user; usually needs root or Administrator")
+	archivePath := flag.String("archive", "", "Scan the home directory packed in this .tar.gz (extracted to a temporary directory that is removed afterwards; bypasses the cache)")
+	portable := flag.String("portable", "", "Scan this directory as the User Data root of a portable Chromium browser (Chrome unless -browser names another)")
+	lenient := flag.Bool("lenient", false, "Accept manifests with trailing commas instead of skipping them (bypasses the cache)")
+	includeDisabledFiles := flag.Bool("include-disabled-files", false, "Also report extension versions the browser has marked for deletion, flagged as such (bypasses the cache)")
+	verifyContents := flag.Bool("verify", false, "Check Chromium extension files against the content hashes in _metadata and flag modified extensions; the hashes' signature isn't checked (bypasses the cache)")
+	verifyIDs := flag.Bool("verify-ids", false, "Derive Chromium extension IDs from the manifest key and flag directories that don't match (bypasses the cache)")
+	profilePattern := flag.String("profile-pattern", "", "Regular expression matching whole Chromium profile directory names to scan, replacing Default and Profile* (bypasses the cache)")
+	stdinPaths := flag.Bool("stdin", false, "Scan the profile directories listed one per line on stdin instead of discovering them (bypasses the cache)")
+	includeSystemProfiles := flag.Bool("include-system-profiles", false, "Also scan the Chromium System Profile and Guest Profile directories (bypasses the cache)")
+	activeProfile := flag.Bool("active-profile", false, "Scan only the last used profile of Chromium browsers, from Local State (bypasses the cache)")
+	typeFilter := flag.String("type", "", "Comma-separated add-on types to report, e.g. extension, or theme,dictionary,locale (default all)")
+	incognitoOnly := flag.Bool("incognito-only", false, "Only report extensions allowed to run in incognito or private windows")
+	nonUserInstalls := flag.Bool("non-user-installs", false, "Only report extensions the user didn't install: by policy, a supervising account, the OEM, browser default, or another program")
+	mergeProfiles := flag.Bool("merge-profiles", false, "Report each extension once per browser, listing the profiles it's installed in, at its highest version")
+	unpackedOnly := flag.Bool("unpacked-only", false, "Only report extensions loaded unpacked in developer mode")
+	includeBuiltin := flag.Bool("include-builtin", false, "Include browser-bundled component extensions, which are hidden by default")
+	excludeIDsFile := flag.String("exclude-ids-file", "", "File of extension IDs (one per line) to leave out of every report")
+	noDefaultExclusions := flag.Bool("no-default-exclusions", false, "Don't leave out the built-in list of component and system extension IDs")
+	since := flag.String("since", "", "Only report extensions first seen or changed version after this RFC3339 time (needs the cache; not combinable with flags that bypass it)")
+	enrich := flag.Bool("enrich", false, "Look up Chromium extensions in their web store (requires network access)")
+	enrichConcurrency := flag.Int("enrich-concurrency", 4, "Maximum concurrent store lookups for -enrich")
+	var knownFile string
+	flag.StringVar(&knownFile, "known", "", "CSV of known extensions (id,name,status,risk) to mark each extension approved, blocked, or unknown")
+	flag.StringVar(&knownFile, "allowlist", "", "Alias for -known")
+	useSyslog := flag.Bool("syslog", false, "Also send the inventory to the local syslog daemon, one message per extension")
+	syslogSummary := flag.Bool("syslog-summary", false, "With -syslog, send a single summary message instead of one per extension")
+	syslogFacility := flag.String("syslog-facility", "user", "Syslog facility for -syslog, e.g. user, daemon, local0")
+	syslogTag := flag.String("syslog-tag", defaultSyslogTag, "Syslog tag for -syslog")
+	compareFiles := flag.String("compare-hosts", "", "Comma-separated -json exports from different machines; report extensions common to all, shared by some, and unique to each, then exit")
+	exportPath := flag.String("export", "", "Also write a zip with the JSON inventory and each extension's manifest and locale files")
+	exportDBPath := flag.String("export-db", "", "Also write the inventory to a new SQLite database in the documented snapshot schema, for SQL analysis")
+	alsoOutput := flag.String("also-output", "", "Also write the results to files, as comma-separated <format>:<file> entries (formats: "+strings.Join(alsoOutputFormats, ", ")+")")
+	outputPath := flag.String("output", "", "Write results to this file instead of stdout, replacing it atomically (- for stdout)")
+	maxExtensions := flag.Int("max-extensions", 0, "Exit with code 7 when more than this many extensions are reported (0 disables)")
+	maxRisk := flag.String("max-risk", "", "Exit with code 7 when an extension's -known risk is above this level ("+strings.Join(known.RiskLevels, ", ")+")")
+	var cacheTTLs []string
+	flag.Func("cache-ttl", "How long cached results stay fresh: a duration for every browser (default "+db.DefaultCacheTTL.String()+"), or browser=duration for one; comma-separated or repeated, e.g. -cache-ttl chrome=10m -cache-ttl firefox=24h", func(s string) error {
+		cacheTTLs = append(cacheTTLs, splitList(s)...)
+		return nil
+	})
+	var profilePaths []string
+	flag.Func("profile-path", "Scan this directory as a profile of the browser picked with -browser instead of discovering profiles (repeatable; bypasses the cache)", func(s string) error {
+		profilePaths = append(profilePaths, s)
+		return nil
+	})
+	enrichTimeout := flag.Duration("enrich-timeout", 10*time.Second, "Timeout per store lookup for -enrich")
 	flag.Parse()
 
-	// Initialize SQLite DB (fatal error if fails)
-	dbConn, err := db.NewDB("./browser_inventory.db")
+	if err := validateOutputFlags(map[string]bool{
+		"-json":        *jsonOutput,
+		"-toml":        *tomlOutput,
+		"-format":      *format != "",
+		"-ids-only":    *idsOnly,
+		"-fingerprint": *fingerprint,
+		"-prometheus":  *prometheus,
+		"-tui":         *tui,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	// The table takes over the terminal, so it needs one on both ends
+	if *tui && (*outputPath != "" || !isTerminal(os.Stdin) || !isTerminal(os.Stdout)) {
+		fmt.Fprintf(os.Stderr, "Error: -tui needs an interactive terminal and can't be combined with -output\n")
+		return exitError
+	}
+
+	alsoOutputs, err := parseAlsoOutputs(*alsoOutput)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error initializing DB: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	// Parse the template up front so mistakes fail before a slow scan
+	var formatTmpl *template.Template
+	if *format != "" {
+		tmpl, err := parseFormatTemplate(*format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+		formatTmpl = tmpl
+	}
+
+	if *useSyslog {
+		if !syslogAvailable {
+			fmt.Fprintf(os.Stderr, "Warning: syslog is not available on %s; ignoring -syslog\n", runtime.GOOS)
+			*useSyslog = false
+		} else if err := checkSyslogFacility(*syslogFacility); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
 	}
-	defer dbConn.Close()
 
 	// List of browsers to query
-	browserList := []string{"Chrome", "Edge", "Firefox"}
-	if *browser != "" {
-		browserList = []string{*browser}
+	bi := browsers.NewBrowserInventory()
+	browserList, err := bi.ParseBrowsers(*browser)
+	if errors.Is(err, browsers.ErrNoBrowsersConfigured) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitNoBrowsers
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	// A portable root replaces the per-OS location, so it only makes sense for
+	// the Chromium browsers and defaults to Chrome
+	if *portable != "" {
+		if *browser == "" {
+			browserList = []string{"Chrome"}
+		}
+		for _, b := range browserList {
+			if config, _ := bi.Config(b); config.IsFirefox {
+				fmt.Fprintf(os.Stderr, "Error: -portable only applies to Chromium browsers, not %s\n", b)
+				return exitError
+			}
+		}
+		bi.Options.PortableRoot = *portable
+	}
+
+	// An archive stands in for the home directory, so it can't be combined
+	// with the flags that pick other roots
+	if *archivePath != "" {
+		if *allUsers || *portable != "" {
+			fmt.Fprintf(os.Stderr, "Error: -archive can't be combined with -all-users or -portable\n")
+			return exitError
+		}
+		root, cleanup, err := extractArchive(*archivePath, *debug)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading archive: %v\n", err)
+			return exitError
+		}
+		defer cleanup()
+		bi.Options.HomeDir = root
+	}
+
+	// Profile paths stand in for one browser's profile discovery
+	if len(profilePaths) > 0 {
+		if len(browserList) != 1 {
+			fmt.Fprintln(os.Stderr, "Error: -profile-path needs -browser naming the one browser the profiles belong to (-stdin detects it per directory)")
+			return exitError
+		}
+		if *stdinPaths || *allUsers || *archivePath != "" || *portable != "" {
+			fmt.Fprintln(os.Stderr, "Error: -profile-path can't be combined with -stdin, -all-users, -archive, or -portable")
+			return exitError
+		}
+	}
+
+	if *profilePattern != "" {
+		re, err := browsers.CompileProfilePattern(*profilePattern)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -profile-pattern: %v\n", err)
+			return exitError
+		}
+		bi.Options.ProfilePattern = re
+	}
+	bi.Options.IncludeSystemProfiles = *includeSystemProfiles
+	bi.Options.RawManifest = *rawManifest
+
+	if *listBrowsers {
+		return printBrowserList(os.Stdout, bi)
+	}
+	ttls, err := parseCacheTTLs(bi, cacheTTLs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	if *doctor {
+		return runDoctor(os.Stdout, bi, browserList)
+	}
+
+	var sinceTime time.Time
+	if *since != "" {
+		t, err := time.Parse(time.RFC3339, *since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -since value %q: %v\n", *since, err)
+			return exitError
+		}
+		sinceTime = t
+	}
+
+	// Results go to w; -output swaps in a temporary file that only replaces
+	// the destination once the run has produced a complete report
+	var w io.Writer = os.Stdout
+	if *outputPath != "" && *outputPath != "-" {
+		outFile, err := createOutputFile(*outputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+		defer func() {
+			if code == exitError || code == exitNotFound {
+				outFile.abort()
+				return
+			}
+			if err := outFile.commit(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *outputPath, err)
+				code = exitError
+				return
+			}
+			if !*quiet {
+				fmt.Fprintf(os.Stderr, "Wrote results to %s\n", *outputPath)
+			}
+		}()
+		w = outFile
+	}
+	// Risk tags are colored only on a terminal; NO_COLOR is the common
+	// convention for turning that off without a flag
+	style := consoleStyle{
+		color:        !*noColor && w == io.Writer(os.Stdout) && isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "",
+		relativeTime: *relativeTime,
+	}
+
+	if *showPaths {
+		return printPaths(w, bi, browserList)
+	}
+
+	// Comparing exports doesn't scan this machine at all
+	if *compareFiles != "" {
+		return reportComparison(w, strings.Split(*compareFiles, ","), *jsonOutput, *compact)
+	}
+
+	// Profiles and orphans come straight from disk, so they don't need the cache
+	if *profileSummary {
+		return reportProfiles(w, bi, browserList, *jsonOutput, *compact, *debug)
+	}
+	if *orphans {
+		return reportOrphans(w, bi, browserList, *jsonOutput, *compact, *debug)
+	}
+
+	maxRiskRank := -1
+	if *maxRisk != "" {
+		rank, ok := known.RiskRank(*maxRisk)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: invalid -max-risk %q (valid: %s)\n", *maxRisk, strings.Join(known.RiskLevels, ", "))
+			return exitError
+		}
+		if knownFile == "" {
+			fmt.Fprintln(os.Stderr, "Error: -max-risk needs risk ratings from -known")
+			return exitError
+		}
+		maxRiskRank = rank
+	}
+
+	var pol *policy.Policy
+	if *policyFile != "" {
+		pol, err = policy.Load(*policyFile, *policyMode)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading policy: %v\n", err)
+			return exitError
+		}
+	}
+
+	// The built-in exclusions are noise filtering, so they stay out of the way
+	// when bundled extensions are asked for
+	exclusions := make(exclude.Set)
+	if !*noDefaultExclusions && !*includeBuiltin && !*includeDisabledFiles {
+		exclusions.Add(exclude.Default())
+	}
+	if *excludeIDsFile != "" {
+		ids, err := exclude.Load(*excludeIDsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading exclusions: %v\n", err)
+			return exitError
+		}
+		exclusions.Add(ids)
+	}
+
+	// Initialize SQLite DB. Without it (read-only directory, permissions)
+	// everything is scanned live and nothing is cached; dbConn stays nil.
+	dbConn, err := db.NewDB(dbPath, bi.BrowserNames())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cache unavailable, scanning without it: %v\n", err)
+		dbConn = nil
+	} else {
+		defer dbConn.Close()
+	}
+	if dbConn == nil && !sinceTime.IsZero() {
+		fmt.Fprintln(os.Stderr, "Error: -since needs the history kept in the cache database")
+		return exitError
+	}
+
+	// Load the known-extensions catalog before scanning so bad files fail fast
+	var catalog known.Catalog
+	if knownFile != "" {
+		catalog, err = known.Load(knownFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading known extensions: %v\n", err)
+			return exitError
+		}
 	}
 
 	// Collect extensions for all relevant browsers
 	var allExtensions []browsers.Extension
-	var fetchError bool // Track if any non-fatal errors occur
-	bi := browsers.NewBrowserInventory()
-	for _, b := range browserList {
+	var failedBrowsers int // Track how many browsers hit non-fatal errors
+	var cachedBrowsers, freshBrowsers int
+	dataMeta := make(map[string]browserDataMeta) // Keyed by browser, successful browsers only
+	bi.Options.IOConcurrency = *ioConcurrency
+	bi.Options.Lenient = *lenient
+	bi.Options.IncludeDisabledFiles = *includeDisabledFiles
+	bi.Options.VerifyIDs = *verifyIDs
+	bi.Options.VerifyContents = *verifyContents
+	bi.Options.NoFallbackLocale = *noFallbackLocale
+	bi.Options.ActiveProfileOnly = *activeProfile
+	if *resume {
+		if dbConn != nil {
+			bi.Options.Checkpoint = dbConn
+		} else {
+			fmt.Fprintln(os.Stderr, "Warning: -resume needs the cache database; scanning every profile")
+		}
+	}
+	// Portable installs, other users' homes, and forensic, verifying,
+	// lenient, strict-locale, or profile-selecting scans are always scanned
+	// fresh and never cached, so they don't mix with the current user's cache
+	useCache := dbConn != nil && *portable == "" && *archivePath == "" && !*allUsers && !*includeDisabledFiles && !*verifyIDs && !*verifyContents && !*lenient && !*noFallbackLocale && !*activeProfile && *profilePattern == "" && !*includeSystemProfiles && !*rawManifest && !*stdinPaths && len(profilePaths) == 0
+	// The name cache belongs to the same user's scans, so scans that bypass
+	// the cache don't read or fill it either. Cached names may also have come
+	// from a fallback locale, which -no-fallback-locale (bypassing the cache
+	// too) rules out.
+	if useCache && !*noNameCache {
+		bi.Options.NameCache = dbConn
+	}
+	if warmCache {
+		if !useCache {
+			fmt.Fprintln(os.Stderr, "Error: -warm-cache needs the cache database and can't be combined with flags that bypass the cache")
+			return exitError
+		}
+		*updateCache = true
+	}
+	// History is only recorded for the current user's cached scans, so the
+	// extensions of any other scan would all look unseen and be dropped
+	if !sinceTime.IsZero() && !useCache {
+		fmt.Fprintln(os.Stderr, "Error: -since can't be combined with flags that bypass the cache")
+		return exitError
+	}
+	scanList, attempted := browserList, len(browserList)
+	if *stdinPaths {
+		paths, err := readPathList(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading profile paths from stdin: %v\n", err)
+			return exitError
+		}
+		for _, path := range paths {
+			extensions, err := bi.ScanProfilePath(path, *debug)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", path, err)
+				failedBrowsers++
+				continue
+			}
+			allExtensions = append(allExtensions, extensions...)
+		}
+		freshBrowsers = len(paths)
+		attempted = len(paths)
+		scanList = nil // The listed profiles replace browser discovery
+	}
+	if len(profilePaths) > 0 {
+		for _, path := range profilePaths {
+			extensions, err := bi.ScanProfileAs(browserList[0], path, *debug)
+			if errors.Is(err, browsers.ErrNotAProfile) {
+				// Possibly a profile that hasn't installed anything yet
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				continue
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", path, err)
+				failedBrowsers++
+				continue
+			}
+			allExtensions = append(allExtensions, extensions...)
+		}
+		freshBrowsers = len(profilePaths)
+		attempted = len(profilePaths)
+		scanList = nil
+	}
+	if *allUsers {
+		homes, err := browsers.UserHomes()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing user homes: %v\n", err)
+			return exitError
+		}
+		failed := make(map[string]bool)
+		for _, home := range homes {
+			bi.Options.HomeDir = home.Path
+			for _, b := range browserList {
+				extensions, err := bi.GetExtensions(b, *debug)
+				if errors.Is(err, browsers.ErrNoBrowsersConfigured) {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					return exitNoBrowsers
+				}
+				if err != nil {
+					if *debug {
+						fmt.Fprintf(os.Stderr, "Error fetching extensions for %s (user %s): %v\n", b, home.User, err)
+					}
+					failed[b] = true
+					failedBrowsers++
+					continue
+				}
+				for i := range extensions {
+					extensions[i].User = home.User
+				}
+				allExtensions = append(allExtensions, extensions...)
+			}
+		}
+		bi.Options.HomeDir = ""
+		// A browser's checkpoints are kept until it has been scanned for every
+		// user, so an interrupted run resumes from the users it finished
+		if bi.Options.Checkpoint != nil {
+			for _, b := range browserList {
+				if failed[b] {
+					continue
+				}
+				if err := dbConn.ClearCheckpoints(b); err != nil && *debug {
+					fmt.Fprintf(os.Stderr, "Error clearing checkpoints for %s: %v\n", b, err)
+				}
+			}
+		}
+		freshBrowsers = len(browserList)
+		attempted = len(homes) * len(browserList)
+		scanList = nil // Already scanned per user above
+	}
+	for _, b := range scanList {
 		var extensions []browsers.Extension
-		if !*updateCache {
-			extensions, err = dbConn.GetExtensions(b)
+		if !*updateCache && useCache {
+			extensions, err = dbConn.GetExtensions(b, ttls.forBrowser(b))
 			if err != nil {
 				if *debug {
 					fmt.Fprintf(os.Stderr, "Error retrieving cached extensions for %s: %v\n", b, err)
 				}
 				// Proceed to fetch fresh extensions
 			} else if extensions != nil {
+				for i := range extensions {
+					extensions[i].FromCache = true
+				}
 				allExtensions = append(allExtensions, extensions...)
+				cachedBrowsers++
+				cachedAt, err := dbConn.CachedAt(b)
+				if err != nil && *debug {
+					fmt.Fprintf(os.Stderr, "Error retrieving cache time for %s: %v\n", b, err)
+				}
+				dm := browserDataMeta{
+					Browser:    b,
+					Source:     sourceCache,
+					UpdatedAt:  cachedAt.UTC().Format(time.RFC3339),
+					AgeSeconds: int64(time.Since(cachedAt).Seconds()),
+				}
+				profiles, ok, err := dbConn.CachedProfiles(b)
+				if err != nil && *debug {
+					fmt.Fprintf(os.Stderr, "Error retrieving cached profile count for %s: %v\n", b, err)
+				}
+				if ok {
+					dm.ProfilesScanned = &profiles
+				}
+				dataMeta[b] = dm
 				continue
 			}
 		}
 
 		// Fetch fresh extensions if cache is stale, empty, or -update-cache is set
 		if extensions == nil || *updateCache {
+			skippedBefore := len(bi.SkippedPaths())
 			extensions, err = bi.GetExtensions(b, *debug)
+			if errors.Is(err, browsers.ErrNoBrowsersConfigured) {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return exitNoBrowsers
+			}
 			if err != nil {
 				if *debug {
 					fmt.Fprintf(os.Stderr, "Error fetching extensions for %s: %v\n", b, err)
 				}
-				fetchError = true
+				failedBrowsers++
 				continue
 			}
+			if bi.Options.Checkpoint != nil {
+				if err := dbConn.ClearCheckpoints(b); err != nil && *debug {
+					fmt.Fprintf(os.Stderr, "Error clearing checkpoints for %s: %v\n", b, err)
+				}
+			}
 
-			// Update cache
-			if err := dbConn.UpdateExtensions(b, extensions); err != nil {
+			// Update cache. A scan that skipped unreadable paths is partial, and
+			// caching it would drop the rows of the profiles it couldn't read.
+			if useCache && len(bi.SkippedPaths()) > skippedBefore {
 				if *debug {
-					fmt.Fprintf(os.Stderr, "Error updating cache for %s: %v\n", b, err)
+					fmt.Fprintf(os.Stderr, "Not caching %s: some paths were skipped for lack of permission\n", b)
+				}
+			} else if useCache {
+				if err := dbConn.UpdateExtensions(b, extensions, bi.ProfilesScanned(b)); err != nil {
+					if *debug {
+						fmt.Fprintf(os.Stderr, "Error updating cache for %s: %v\n", b, err)
+					}
+					// Still use the fetched extensions even if cache update fails
 				}
-				// Still use the fetched extensions even if cache update fails
 			}
 			allExtensions = append(allExtensions, extensions...)
+			freshBrowsers++
+			profiles := bi.ProfilesScanned(b)
+			dataMeta[b] = browserDataMeta{Browser: b, Source: sourceFresh, UpdatedAt: time.Now().UTC().Format(time.RFC3339), ProfilesScanned: &profiles}
+		}
+	}
+
+	// Warming only fills the cache; reporting is left to the next run
+	if warmCache {
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "Cached %d extensions (%d of %d browsers scanned)\n", len(allExtensions), freshBrowsers, attempted)
+		}
+		return exitCode(failedBrowsers, attempted, len(allExtensions))
+	}
+
+	if !*includeBuiltin && !*includeDisabledFiles {
+		allExtensions = browsers.ExcludeBuiltin(allExtensions)
+	}
+	if len(exclusions) > 0 {
+		before := len(allExtensions)
+		allExtensions = exclusions.Filter(allExtensions)
+		if *debug && len(allExtensions) < before {
+			fmt.Fprintf(os.Stderr, "Excluded %d extensions by ID\n", before-len(allExtensions))
+		}
+	}
+	if *unpackedOnly {
+		allExtensions = browsers.FilterUnpacked(allExtensions)
+	}
+	if *incognitoOnly {
+		allExtensions = browsers.FilterIncognito(allExtensions)
+	}
+	if *nonUserInstalls {
+		allExtensions = browsers.FilterNonUserInstalls(allExtensions)
+	}
+	if types := splitList(*typeFilter); len(types) > 0 {
+		allExtensions = browsers.FilterTypes(allExtensions, types)
+	}
+
+	if !sinceTime.IsZero() {
+		allExtensions = changedSince(dbConn, allExtensions, sinceTime, *debug)
+	}
+
+	// Enrich after filtering so only reported extensions are looked up
+	if *enrich {
+		client := webstore.NewClient(*enrichConcurrency, *enrichTimeout)
+		enrichFromStore(dbConn, bi, client, allExtensions, *debug)
+	}
+
+	if catalog != nil {
+		catalog.Apply(allExtensions)
+	}
+
+	if *mergeProfiles {
+		allExtensions = browsers.MergeProfiles(allExtensions)
+	}
+
+	// Thresholds are evaluated on the filtered inventory and reported on stderr
+	// so they don't disturb machine-readable output
+	thresholdExceeded := checkThresholds(allExtensions, *maxExtensions, maxRiskRank)
+
+	if *getID != "" {
+		matches := browsers.FilterByID(allExtensions, *getID)
+		if len(matches) == 0 {
+			fmt.Fprintf(os.Stderr, "Extension %s not found\n", *getID)
+			return exitNotFound
+		}
+		if *jsonOutput {
+			if err := printJSON(w, output{Extensions: matches, Total: len(matches), UniqueTotal: len(uniqueIDs(matches))}, *compact); err != nil {
+				fmt.Fprintf(os.Stderr, "Error marshalling JSON: %v\n", err)
+				return exitError
+			}
+		} else {
+			for i, ext := range matches {
+				printExtension(w, i, ext, true, style)
+			}
+		}
+		return exitCode(failedBrowsers, attempted, len(matches))
+	}
+
+	// The policy sees the filtered inventory: excluded IDs are never
+	// violations, in either mode
+	if pol != nil {
+		violations := pol.Violations(allExtensions)
+		if *jsonOutput {
+			out := policyOutput{Mode: pol.Mode, Compliant: len(violations) == 0, Violations: violations, Total: len(violations)}
+			if err := printJSON(w, out, *compact); err != nil {
+				fmt.Fprintf(os.Stderr, "Error marshalling JSON: %v\n", err)
+				return exitError
+			}
+		} else {
+			printViolations(w, pol.Mode, violations, style)
+		}
+		if len(violations) > 0 {
+			return exitPolicyFailed
+		}
+		if code := exitCode(failedBrowsers, attempted, len(allExtensions)); code != exitNoExtensions {
+			return code
 		}
+		return exitOK
 	}
 
-	// Output logic
-	if *jsonOutput {
-		if fetchError {
-			// Return empty JSON if any errors occurred
-			fmt.Println(`{"extensions": [], "total": 0}`)
+	if *permissionSummary {
+		summary := browsers.SummarizePermissions(allExtensions)
+		if *jsonOutput {
+			if err := printJSON(w, permissionSummaryOutput{Permissions: summary, Total: len(allExtensions)}, *compact); err != nil {
+				fmt.Fprintf(os.Stderr, "Error marshalling JSON: %v\n", err)
+				return exitError
+			}
 		} else {
-			out := output{
-				Extensions: allExtensions,
-				Total:      len(allExtensions),
+			printPermissionSummary(w, summary, len(allExtensions))
+		}
+		return exitCode(failedBrowsers, attempted, len(allExtensions))
+	}
+
+	if *duplicates {
+		groups := browsers.FindDuplicates(allExtensions)
+		if *jsonOutput {
+			if err := printJSON(w, duplicatesOutput{Duplicates: groups, Total: len(groups)}, *compact); err != nil {
+				fmt.Fprintf(os.Stderr, "Error marshalling JSON: %v\n", err)
+				return exitError
 			}
-			jsonData, err := json.MarshalIndent(out, "", "  ")
-			if err != nil {
+		} else {
+			printDuplicates(w, groups)
+		}
+		return exitCode(failedBrowsers, attempted, len(allExtensions))
+	}
+
+	if *countOnly {
+		if *jsonOutput {
+			if err := printJSON(w, countOutput{Total: len(allExtensions)}, *compact); err != nil {
 				fmt.Fprintf(os.Stderr, "Error marshalling JSON: %v\n", err)
-				os.Exit(1)
+				return exitError
+			}
+		} else {
+			fmt.Fprintln(w, len(allExtensions))
+		}
+		// A count of zero is still an answer, so only scan failures count
+		if code := exitCode(failedBrowsers, attempted, len(allExtensions)); code != exitNoExtensions {
+			return code
+		}
+		return exitOK
+	}
+
+	// Installed/has-profile summary, one entry per selected browser. Profile
+	// detection is per home directory, so it is left out for -all-users.
+	var statuses []browsers.BrowserStatus
+	for _, b := range scanList {
+		statuses = append(statuses, bi.DetectBrowsers(b)...)
+	}
+
+	meta := newScanMeta(cachedBrowsers, freshBrowsers)
+	for _, b := range browserList {
+		if dm, ok := dataMeta[b]; ok {
+			meta.Browsers = append(meta.Browsers, dm)
+		}
+	}
+	var fileErrors []browsers.FileError
+	var skipped []browsers.SkippedPath
+	if *reportErrors {
+		fileErrors = bi.FileErrors()
+		skipped = bi.SkippedPaths()
+	}
+	// -debug already listed them as they were skipped
+	if n := len(bi.SkippedPaths()); n > 0 && !*debug {
+		fmt.Fprintf(os.Stderr, "Warning: Skipped %d path(s) for lack of read permission; use -debug or -report-errors (JSON/TOML) to list them\n", n)
+	}
+	var keyConflicts []browsers.KeyConflict
+	if *verifyIDs {
+		keyConflicts = browsers.FindKeyConflicts(allExtensions)
+		warnKeyConflicts(keyConflicts)
+	}
+
+	// Output logic: one scan, serialized once per requested format
+	primaryFormat := outputConsole
+	switch {
+	case *fingerprint:
+		primaryFormat = outputFingerprint
+	case *idsOnly:
+		primaryFormat = outputIDs
+	case *prometheus:
+		primaryFormat = outputPrometheus
+	case formatTmpl != nil:
+		primaryFormat = outputTemplate
+	case *tomlOutput:
+		primaryFormat = outputTOML
+	case *jsonOutput:
+		primaryFormat = outputJSON
+	}
+	rep := report{
+		extensions:   allExtensions,
+		statuses:     statuses,
+		dataMeta:     dataMeta,
+		meta:         meta,
+		fileErrors:   fileErrors,
+		skipped:      skipped,
+		keyConflicts: keyConflicts,
+		failed:       failedBrowsers > 0,
+		group:        *group,
+		compact:      *compact,
+		tmpl:         formatTmpl,
+		style:        style,
+	}
+	if *tui {
+		if err := runTUI(allExtensions, style); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+	} else if err := writeReport(w, primaryFormat, rep); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	for _, out := range alsoOutputs {
+		if err := writeAlsoOutput(out, rep); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", out.path, err)
+			return exitError
+		}
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "Wrote %s results to %s\n", out.format, out.path)
+		}
+	}
+
+	if *exportPath != "" {
+		if err := writeExport(*exportPath, rep.output(), *debug); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *exportPath, err)
+			return exitError
+		}
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "Wrote export to %s\n", *exportPath)
+		}
+	}
+
+	if *exportDBPath != "" {
+		info := map[string]string{
+			"hostname":     meta.Hostname,
+			"os":           meta.OS,
+			"timestamp":    meta.Timestamp,
+			"tool_version": meta.ToolVersion,
+			"source":       meta.Source,
+		}
+		if err := db.ExportSnapshot(*exportDBPath, allExtensions, info); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *exportDBPath, err)
+			return exitError
+		}
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "Wrote database export to %s\n", *exportDBPath)
+		}
+	}
+
+	if *useSyslog {
+		if err := sendSyslog(*syslogFacility, *syslogTag, syslogMessages(allExtensions, failedBrowsers, *syslogSummary)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+	}
+
+	if thresholdExceeded {
+		return exitThreshold
+	}
+	return exitCode(failedBrowsers, attempted, len(allExtensions))
+}
+
+// checkThresholds warns about and reports whether the inventory exceeds
+// -max-extensions or has an extension rated above -max-risk. A limit of 0 or a
+// rank below 0 disables the check.
+func checkThresholds(extensions []browsers.Extension, maxExtensions, maxRiskRank int) bool {
+	exceeded := false
+	if maxExtensions > 0 && len(extensions) > maxExtensions {
+		fmt.Fprintf(os.Stderr, "Warning: %d extensions found, above -max-extensions %d\n", len(extensions), maxExtensions)
+		exceeded = true
+	}
+	if maxRiskRank >= 0 {
+		for _, ext := range extensions {
+			if rank, ok := known.RiskRank(ext.Risk); ok && rank > maxRiskRank {
+				fmt.Fprintf(os.Stderr, "Warning: %s (%s) has risk %s, above -max-risk %s\n", ext.DisplayName(), ext.ID, ext.Risk, known.RiskLevels[maxRiskRank])
+				exceeded = true
 			}
-			fmt.Println(string(jsonData))
+		}
+	}
+	return exceeded
+}
+
+// reportProfiles prints the profiles of the selected browsers
+func reportProfiles(w io.Writer, bi *browsers.BrowserInventory, browserList []string, jsonOutput, compact, debug bool) int {
+	var all []browsers.Profile
+	for _, b := range browserList {
+		profiles, err := bi.ListProfiles(b, debug)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing profiles for %s: %v\n", b, err)
+			return exitError
+		}
+		all = append(all, profiles...)
+	}
+
+	if jsonOutput {
+		if err := printJSON(w, profilesOutput{Profiles: all, Total: len(all)}, compact); err != nil {
+			fmt.Fprintf(os.Stderr, "Error marshalling JSON: %v\n", err)
+			return exitError
+		}
+	} else {
+		printProfiles(w, browserList, all)
+	}
+	return exitOK
+}
+
+// reportOrphans prints the orphaned extension directories for the selected
+// browsers
+func reportOrphans(w io.Writer, bi *browsers.BrowserInventory, browserList []string, jsonOutput, compact, debug bool) int {
+	var all []browsers.Orphan
+	for _, b := range browserList {
+		orphans, err := bi.FindOrphans(b, debug)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding orphans for %s: %v\n", b, err)
+			return exitError
+		}
+		all = append(all, orphans...)
+	}
+
+	if jsonOutput {
+		out := orphansOutput{Orphans: all, Total: len(all)}
+		for _, o := range all {
+			out.Size += o.Size
+		}
+		if err := printJSON(w, out, compact); err != nil {
+			fmt.Fprintf(os.Stderr, "Error marshalling JSON: %v\n", err)
+			return exitError
+		}
+	} else {
+		printOrphans(w, all)
+	}
+	return exitOK
+}
+
+// reportComparison prints how the extensions in several -json exports
+// overlap across hosts
+func reportComparison(w io.Writer, paths []string, jsonOutput, compact bool) int {
+	var files []string
+	for _, p := range paths {
+		if p = strings.TrimSpace(p); p != "" {
+			files = append(files, p)
+		}
+	}
+	if len(files) < 2 {
+		fmt.Fprintln(os.Stderr, "Error: -compare-hosts needs at least two JSON exports")
+		return exitError
+	}
+	inventories, err := loadInventories(files)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading inventories: %v\n", err)
+		return exitError
+	}
+
+	out := compareHosts(inventories)
+	if jsonOutput {
+		if err := printJSON(w, out, compact); err != nil {
+			fmt.Fprintf(os.Stderr, "Error marshalling JSON: %v\n", err)
+			return exitError
 		}
 	} else {
-		if len(allExtensions) == 0 {
-			fmt.Println("No extensions found.")
-			return
+		printComparison(w, out)
+	}
+	return exitOK
+}
+
+// newScanMeta captures the metadata for this run
+func newScanMeta(cachedBrowsers, freshBrowsers int) *scanMeta {
+	hostname, _ := os.Hostname()
+	source := sourceMixed
+	switch {
+	case freshBrowsers == 0:
+		source = sourceCache
+	case cachedBrowsers == 0:
+		source = sourceFresh
+	}
+	return &scanMeta{
+		Hostname:    hostname,
+		OS:          runtime.GOOS,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		ToolVersion: version,
+		Source:      source,
+	}
+}
+
+// printBrowserList prints every configured browser, whatever -browser
+// selects, with its scanner type and the data path resolved for this OS
+func printBrowserList(w io.Writer, bi *browsers.BrowserInventory) int {
+	for _, name := range bi.BrowserNames() {
+		config, _ := bi.Config(name)
+		paths, err := bi.ResolvePaths(name)
+		if err != nil || len(paths) == 0 {
+			fmt.Fprintf(os.Stderr, "Error resolving paths for %s: %v\n", name, err)
+			return exitError
+		}
+		bp := paths[0]
+		var location string
+		switch {
+		case bp.Unsupported:
+			location = "unsupported on " + runtime.GOOS
+		case bp.Error != "":
+			location = "error: " + bp.Error
+		case len(bp.Paths) > 0:
+			status := "missing"
+			if bp.Paths[0].Exists {
+				status = "exists"
+			}
+			location = fmt.Sprintf("%s (%s)", bp.Paths[0].Path, status)
+		}
+		fmt.Fprintf(w, "%s (%s): %s\n", config.Name, config.ScannerType(), location)
+	}
+	return exitOK
+}
+
+// printPaths prints the resolved scan paths for the selected browsers
+func printPaths(w io.Writer, bi *browsers.BrowserInventory, browserList []string) int {
+	var all []browsers.BrowserPaths
+	for _, b := range browserList {
+		paths, err := bi.ResolvePaths(b)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving paths: %v\n", err)
+			return exitError
+		}
+		all = append(all, paths...)
+	}
+
+	for _, bp := range all {
+		fmt.Fprintf(w, "%s:\n", bp.Browser)
+		if bp.Unsupported {
+			fmt.Fprintf(w, "   Unsupported OS %s\n", runtime.GOOS)
+			continue
+		}
+		if bp.Error != "" {
+			fmt.Fprintf(w, "   Error: %s\n", bp.Error)
+			continue
 		}
+		for _, p := range bp.Paths {
+			status := "missing"
+			if p.Exists {
+				status = "exists"
+			}
+			fmt.Fprintf(w, "   %s: %s (%s)\n", p.Label, p.Path, status)
+		}
+	}
+	return exitOK
+}
 
-		fmt.Println("Browser Extensions:")
-		fmt.Println("===================")
-		for i, ext := range allExtensions {
-			fmt.Printf("%d. %s\n", i+1, ext.Name)
-			fmt.Printf("   Browser: %s\n", ext.Browser)
-			fmt.Printf("   Version: %s\n", ext.Version)
-			fmt.Printf("   ID: %s\n", ext.ID)
-			fmt.Printf("   Enabled: %v\n", ext.Enabled)
-			if ext.Profile != "" {
-				fmt.Printf("   Profile: %s\n", ext.Profile)
+// changedSince keeps extensions whose version was first recorded after since,
+// i.e. new installs and version changes, stamping each with its first-seen time
+func changedSince(dbConn *db.DB, extensions []browsers.Extension, since time.Time, debug bool) []browsers.Extension {
+	history := make(map[string]map[string]time.Time)
+	var changed []browsers.Extension
+	for _, ext := range extensions {
+		firstSeen, ok := history[ext.Browser]
+		if !ok {
+			var err error
+			firstSeen, err = dbConn.FirstSeen(ext.Browser)
+			if err != nil && debug {
+				fmt.Fprintf(os.Stderr, "Error retrieving history for %s: %v\n", ext.Browser, err)
 			}
-			fmt.Println("------------------")
+			history[ext.Browser] = firstSeen
+		}
+
+		seen, ok := firstSeen[db.HistoryKey(ext.ID, ext.Profile, ext.Version)]
+		if !ok || !seen.After(since) {
+			continue
+		}
+		ext.FirstSeen = &seen
+		changed = append(changed, ext)
+	}
+	return changed
+}
+
+// validateOutputFlags ensures at most one output format flag is set
+func validateOutputFlags(formats map[string]bool) error {
+	var active []string
+	for name, set := range formats {
+		if set {
+			active = append(active, name)
+		}
+	}
+	if len(active) > 1 {
+		sort.Strings(active)
+		return fmt.Errorf("only one output format may be selected, got %s", strings.Join(active, ", "))
+	}
+	return nil
+}
+
+// cacheTTLs holds the cache lifetime per browser, from -cache-ttl
+type cacheTTLs struct {
+	global     time.Duration
+	perBrowser map[string]time.Duration // Keyed by configured browser name
+}
+
+// forBrowser returns the cache lifetime for a configured browser name
+func (t cacheTTLs) forBrowser(browser string) time.Duration {
+	if ttl, ok := t.perBrowser[browser]; ok {
+		return ttl
+	}
+	return t.global
+}
+
+// parseCacheTTLs reads -cache-ttl entries: a bare duration sets the lifetime
+// for every browser without its own browser=duration entry. Later entries
+// win.
+func parseCacheTTLs(bi *browsers.BrowserInventory, entries []string) (cacheTTLs, error) {
+	ttls := cacheTTLs{global: db.DefaultCacheTTL, perBrowser: make(map[string]time.Duration)}
+	for _, entry := range entries {
+		name, value, scoped := strings.Cut(entry, "=")
+		if !scoped {
+			value = name
+		}
+		ttl, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || ttl < 0 {
+			return cacheTTLs{}, fmt.Errorf("invalid -cache-ttl %q, want a duration such as 30m or browser=duration", entry)
+		}
+		if !scoped {
+			ttls.global = ttl
+			continue
+		}
+		config, ok := bi.Config(strings.TrimSpace(name))
+		if !ok {
+			return cacheTTLs{}, fmt.Errorf("invalid -cache-ttl %q: unknown browser %q", entry, strings.TrimSpace(name))
+		}
+		ttls.perBrowser[config.Name] = ttl
+	}
+	return ttls, nil
+}
+
+// splitList splits a comma-separated flag value, trimming spaces and
+// dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// readPathList reads one path per line for -stdin, skipping blank lines and
+// # comments
+func readPathList(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths, scanner.Err()
+}
+
+// uniqueIDs returns extension IDs in first-seen order without duplicates
+func uniqueIDs(extensions []browsers.Extension) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, ext := range extensions {
+		if seen[ext.ID] {
+			continue
 		}
-		fmt.Printf("Total extensions: %d\n", len(allExtensions))
+		seen[ext.ID] = true
+		ids = append(ids, ext.ID)
+	}
+	return ids
+}
+
+// exitCode maps the scan outcome to the documented exit code contract
+func exitCode(failed, attempted, found int) int {
+	switch {
+	case failed > 0 && failed == attempted:
+		return exitAllFailed
+	case failed > 0:
+		return exitPartialFailure
+	case found == 0:
+		return exitNoExtensions
+	default:
+		return exitOK
 	}
 }
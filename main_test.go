@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go-browser-inventory/db"
+	"go-browser-inventory/internal/browsers"
+)
+
+func TestParseCacheTTLs(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		want    map[string]time.Duration
+	}{
+		{
+			name:    "default",
+			entries: nil,
+			want:    map[string]time.Duration{"Chrome": db.DefaultCacheTTL, "Firefox": db.DefaultCacheTTL},
+		},
+		{
+			name:    "global",
+			entries: []string{"2h"},
+			want:    map[string]time.Duration{"Chrome": 2 * time.Hour, "Firefox": 2 * time.Hour},
+		},
+		{
+			name:    "per browser",
+			entries: []string{"firefox=24h", " Chrome = 5m "},
+			want:    map[string]time.Duration{"Chrome": 5 * time.Minute, "Edge": db.DefaultCacheTTL, "Firefox": 24 * time.Hour},
+		},
+		{
+			name:    "per browser over global in any order",
+			entries: []string{"Firefox=0s", "1h"},
+			want:    map[string]time.Duration{"Chrome": time.Hour, "Firefox": 0},
+		},
+		{
+			name:    "later entries win",
+			entries: []string{"Edge=1h", "Edge=3h"},
+			want:    map[string]time.Duration{"Edge": 3 * time.Hour},
+		},
+	}
+	bi := browsers.NewBrowserInventory()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttls, err := parseCacheTTLs(bi, tt.entries)
+			if err != nil {
+				t.Fatalf("parseCacheTTLs(%q): %v", tt.entries, err)
+			}
+			for browser, want := range tt.want {
+				if got := ttls.forBrowser(browser); got != want {
+					t.Errorf("forBrowser(%s) = %v, want %v", browser, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCacheTTLsInvalid(t *testing.T) {
+	bi := browsers.NewBrowserInventory()
+	for _, entry := range []string{"soon", "-1h", "Chrome=", "Netscape=1h", "=1h"} {
+		if _, err := parseCacheTTLs(bi, []string{entry}); err == nil {
+			t.Errorf("parseCacheTTLs(%q) succeeded, want an error", entry)
+		}
+	}
+}
+
+// TestPrintPaths checks that -paths writes to the given writer, so -output
+// applies to it
+func TestPrintPaths(t *testing.T) {
+	home := t.TempDir()
+	bi := browsers.NewBrowserInventory()
+	bi.Options.HomeDir = home
+
+	var b strings.Builder
+	if code := printPaths(&b, bi, []string{"Chrome"}); code != exitOK {
+		t.Fatalf("printPaths returned %d", code)
+	}
+	got := b.String()
+	if !strings.HasPrefix(got, "Chrome:\n") || !strings.Contains(got, home) || !strings.Contains(got, "(missing)") {
+		t.Errorf("printPaths wrote:\n%s", got)
+	}
+}
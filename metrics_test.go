@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"go-browser-inventory/internal/browsers"
+)
+
+func TestFormatPrometheus(t *testing.T) {
+	extensions := []browsers.Extension{
+		{Browser: "Firefox", Risk: "low"},
+		{Browser: "Chrome", Risk: "High"},
+		{Browser: "Chrome", Risk: " high ", InstallSource: browsers.InstallSourceExternal},
+		{Browser: "Chrome", Risk: "catastrophic", InstallSource: browsers.InstallSourceUnpacked},
+		{Browser: "Edge", InstallSource: "enterprise-policy"},
+		{Browser: `Odd "Browser"`},
+	}
+	want := `# HELP browser_extensions Number of extensions found per browser.
+# TYPE browser_extensions gauge
+browser_extensions{browser="Chrome"} 3
+browser_extensions{browser="Edge"} 1
+browser_extensions{browser="Firefox"} 1
+browser_extensions{browser="Odd \"Browser\""} 1
+# HELP browser_extensions_by_risk Number of extensions per -known risk level.
+# TYPE browser_extensions_by_risk gauge
+browser_extensions_by_risk{level="low"} 1
+browser_extensions_by_risk{level="medium"} 0
+browser_extensions_by_risk{level="high"} 2
+browser_extensions_by_risk{level="critical"} 0
+browser_extensions_by_risk{level="unknown"} 3
+# HELP browser_extensions_by_source Number of extensions per install source.
+# TYPE browser_extensions_by_source gauge
+browser_extensions_by_source{source="default"} 3
+browser_extensions_by_source{source="external"} 1
+browser_extensions_by_source{source="unpacked"} 1
+browser_extensions_by_source{source="other"} 1
+`
+	if got := formatPrometheus(extensions); got != want {
+		t.Errorf("formatPrometheus() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestFormatPrometheusEmpty checks that every risk and source series is
+// written even when there is nothing to count, so the set of series never
+// changes between scans
+func TestFormatPrometheusEmpty(t *testing.T) {
+	got := formatPrometheus(nil)
+	for _, series := range []string{
+		`browser_extensions_by_risk{level="low"} 0`,
+		`browser_extensions_by_risk{level="medium"} 0`,
+		`browser_extensions_by_risk{level="high"} 0`,
+		`browser_extensions_by_risk{level="critical"} 0`,
+		`browser_extensions_by_risk{level="unknown"} 0`,
+		`browser_extensions_by_source{source="default"} 0`,
+		`browser_extensions_by_source{source="external"} 0`,
+		`browser_extensions_by_source{source="unpacked"} 0`,
+		`browser_extensions_by_source{source="other"} 0`,
+	} {
+		if !strings.Contains(got, series+"\n") {
+			t.Errorf("formatPrometheus(nil) is missing %s", series)
+		}
+	}
+	if strings.Contains(got, "browser_extensions{") {
+		t.Errorf("formatPrometheus(nil) wrote a per-browser series:\n%s", got)
+	}
+}
+
+func TestMetricsLabels(t *testing.T) {
+	risks := []struct {
+		risk string
+		want string
+	}{
+		{"", "unknown"},
+		{"low", "low"},
+		{"CRITICAL", "critical"},
+		{" Medium ", "medium"},
+		{"severe", "unknown"},
+	}
+	for _, tt := range risks {
+		if got := metricsRisk(tt.risk); got != tt.want {
+			t.Errorf("metricsRisk(%q) = %q, want %q", tt.risk, got, tt.want)
+		}
+	}
+
+	sources := []struct {
+		source string
+		want   string
+	}{
+		{"", "default"},
+		{browsers.InstallSourceExternal, "external"},
+		{browsers.InstallSourceUnpacked, "unpacked"},
+		{"enterprise-policy", "other"},
+		{"sideloaded", "other"},
+	}
+	for _, tt := range sources {
+		if got := metricsSource(tt.source); got != tt.want {
+			t.Errorf("metricsSource(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
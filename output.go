@@ -0,0 +1,719 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"go-browser-inventory/internal/browsers"
+	"go-browser-inventory/internal/known"
+
+	"github.com/BurntSushi/toml"
+)
+
+// groupExtensions nests extensions by browser and profile for -group
+func groupExtensions(extensions []browsers.Extension) map[string]browserGroup {
+	groups := make(map[string]browserGroup)
+	for _, ext := range extensions {
+		profile := ext.Profile
+		if ext.User != "" {
+			profile = ext.User + "/" + profile
+		}
+		bg, ok := groups[ext.Browser]
+		if !ok {
+			bg.Profiles = make(map[string]profileGroup)
+		}
+		pg := bg.Profiles[profile]
+		pg.Extensions = append(pg.Extensions, ext)
+		pg.Total++
+		bg.Profiles[profile] = pg
+		bg.Total++
+		groups[ext.Browser] = bg
+	}
+	return groups
+}
+
+// Output formats: the primary one is picked by flags, extra ones are named
+// in -also-output
+const (
+	outputConsole     = "console"
+	outputJSON        = "json"
+	outputTOML        = "toml"
+	outputIDs         = "ids"
+	outputFingerprint = "fingerprint"
+	outputPrometheus  = "prometheus"
+	outputTemplate    = "template" // -format, primary output only
+)
+
+// alsoOutputFormats are the formats -also-output accepts
+var alsoOutputFormats = []string{outputConsole, outputJSON, outputTOML, outputIDs, outputFingerprint, outputPrometheus}
+
+// report is a run's results and the flags that shape their output, so every
+// requested format serializes the same scan
+type report struct {
+	extensions []browsers.Extension
+	statuses   []browsers.BrowserStatus
+	dataMeta   map[string]browserDataMeta
+	meta       *scanMeta
+	fileErrors []browsers.FileError
+	// skipped is set with -report-errors
+	skipped []browsers.SkippedPath
+	// keyConflicts is set with -verify-ids
+	keyConflicts []browsers.KeyConflict
+	// failed makes JSON and TOML report nothing, as when a browser failed
+	failed  bool
+	group   bool
+	compact bool
+	tmpl    *template.Template
+	style   consoleStyle
+}
+
+// output returns the JSON/TOML document for the report
+func (r report) output() output {
+	return output{Extensions: r.extensions, Total: len(r.extensions), UniqueTotal: len(uniqueIDs(r.extensions)), Browsers: r.statuses, Meta: r.meta, Errors: r.fileErrors, Skipped: r.skipped, KeyConflicts: r.keyConflicts}
+}
+
+// writeReport writes the report to w in one of the output formats
+func writeReport(w io.Writer, format string, r report) error {
+	switch format {
+	case outputFingerprint:
+		fmt.Fprintln(w, browsers.Fingerprint(r.extensions))
+	case outputIDs:
+		for _, id := range uniqueIDs(r.extensions) {
+			fmt.Fprintln(w, id)
+		}
+	case outputPrometheus:
+		fmt.Fprint(w, formatPrometheus(r.extensions))
+	case outputTemplate:
+		return printTemplate(w, r.tmpl, r.extensions)
+	case outputTOML:
+		if r.failed {
+			// Mirror the JSON behavior of reporting nothing when errors occurred
+			fmt.Fprintln(w, "total = 0")
+		} else if err := printTOML(w, r.output()); err != nil {
+			return fmt.Errorf("failed to marshal TOML: %w", err)
+		}
+	case outputJSON:
+		if r.failed {
+			// Return empty JSON if any errors occurred
+			if r.group {
+				fmt.Fprintln(w, `{"groups": {}, "total": 0}`)
+			} else {
+				fmt.Fprintln(w, `{"extensions": [], "total": 0}`)
+			}
+			return nil
+		}
+		var v any = r.output()
+		if r.group {
+			v = groupedOutput{Groups: groupExtensions(r.extensions), Total: len(r.extensions), UniqueTotal: len(uniqueIDs(r.extensions)), Browsers: r.statuses, Meta: r.meta, Errors: r.fileErrors, Skipped: r.skipped, KeyConflicts: r.keyConflicts}
+		}
+		if err := printJSON(w, v, r.compact); err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+	default:
+		printConsole(w, r.extensions, r.style)
+		printBrowserStatuses(w, r.statuses, r.dataMeta)
+	}
+	return nil
+}
+
+// alsoOutput is one extra artifact requested with -also-output
+type alsoOutput struct {
+	format string
+	path   string
+}
+
+// parseAlsoOutputs parses -also-output's comma-separated <format>:<file>
+// entries. Files must be named; stdout belongs to the primary output.
+func parseAlsoOutputs(s string) ([]alsoOutput, error) {
+	var outs []alsoOutput
+	for _, entry := range splitList(s) {
+		format, path, ok := strings.Cut(entry, ":")
+		format = strings.ToLower(strings.TrimSpace(format))
+		path = strings.TrimSpace(path)
+		if !ok || path == "" || path == "-" {
+			return nil, fmt.Errorf("invalid -also-output entry %q, want <format>:<file>", entry)
+		}
+		known := false
+		for _, f := range alsoOutputFormats {
+			known = known || f == format
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown -also-output format %q (valid: %s)", format, strings.Join(alsoOutputFormats, ", "))
+		}
+		outs = append(outs, alsoOutput{format: format, path: path})
+	}
+	return outs, nil
+}
+
+// writeAlsoOutput writes the report to an -also-output file, replacing it
+// atomically like -output. Console output to a file is never colored.
+func writeAlsoOutput(out alsoOutput, r report) error {
+	f, err := createOutputFile(out.path)
+	if err != nil {
+		return err
+	}
+	r.style.color = false
+	if err := writeReport(f, out.format, r); err != nil {
+		f.abort()
+		return err
+	}
+	return f.commit()
+}
+
+// printJSON writes v as JSON to w, indented unless compact is set
+func printJSON(w io.Writer, v any, compact bool) error {
+	var jsonData []byte
+	var err error
+	if compact {
+		jsonData, err = json.Marshal(v)
+	} else {
+		jsonData, err = json.MarshalIndent(v, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, string(jsonData))
+	return nil
+}
+
+// printTOML writes v as TOML to w
+func printTOML(w io.Writer, v any) error {
+	return toml.NewEncoder(w).Encode(v)
+}
+
+// outputFile is a result file written to a temporary sibling and renamed
+// into place on commit, so a failed run never clobbers a good previous report
+type outputFile struct {
+	*os.File
+	path string
+}
+
+// createOutputFile opens a temporary file next to path for -output
+func createOutputFile(path string) (*outputFile, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	return &outputFile{File: f, path: path}, nil
+}
+
+// commit flushes the temporary file and moves it over the destination
+func (f *outputFile) commit() error {
+	if err := f.Chmod(0o644); err != nil {
+		f.abort()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.abort()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// abort discards the temporary file, leaving any previous report in place
+func (f *outputFile) abort() {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// templateFuncs are helpers available to -format templates
+var templateFuncs = template.FuncMap{
+	// pad right-pads s with spaces to width
+	"pad": func(width int, s string) string {
+		return fmt.Sprintf("%-*s", width, s)
+	},
+	// padLeft left-pads s with spaces to width
+	"padLeft": func(width int, s string) string {
+		return fmt.Sprintf("%*s", width, s)
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// parseFormatTemplate parses a -format value: a text/template string, or
+// @path to read the template from a file. Inline templates may use \t and \n
+// escapes since shells pass them through literally.
+func parseFormatTemplate(spec string) (*template.Template, error) {
+	text := spec
+	if path, ok := strings.CutPrefix(spec, "@"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file: %w", err)
+		}
+		text = string(data)
+	} else {
+		text = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(text)
+	}
+
+	tmpl, err := template.New("format").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -format template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// printTemplate executes tmpl once per extension, ending each entry with a
+// newline unless the template already does
+func printTemplate(w io.Writer, tmpl *template.Template, extensions []browsers.Extension) error {
+	for _, ext := range extensions {
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, ext); err != nil {
+			return fmt.Errorf("failed to execute -format template: %w", err)
+		}
+		out := buf.String()
+		if !strings.HasSuffix(out, "\n") {
+			out += "\n"
+		}
+		fmt.Fprint(w, out)
+	}
+	return nil
+}
+
+// printConsole writes the human-readable extension listing
+func printConsole(w io.Writer, extensions []browsers.Extension, style consoleStyle) {
+	if len(extensions) == 0 {
+		fmt.Fprintln(w, "No extensions found.")
+		return
+	}
+
+	fmt.Fprintln(w, "Browser Extensions:")
+	fmt.Fprintln(w, "===================")
+	for i, ext := range extensions {
+		printExtension(w, i, ext, false, style)
+	}
+	fmt.Fprintf(w, "Total extensions: %d installs across %d profiles (%d unique)\n", len(extensions), countProfiles(extensions), len(uniqueIDs(extensions)))
+}
+
+// countProfiles returns the number of distinct browser profiles in extensions
+func countProfiles(extensions []browsers.Extension) int {
+	profiles := make(map[[2]string]bool)
+	for _, ext := range extensions {
+		profiles[[2]string{ext.Browser, ext.Profile}] = true
+	}
+	return len(profiles)
+}
+
+// printExtension writes one numbered console entry. Detailed entries also
+// include permissions and on-disk paths.
+func printExtension(w io.Writer, i int, ext browsers.Extension, detailed bool, style consoleStyle) {
+	if tag := riskTag(ext.Risk, style.color); tag != "" {
+		fmt.Fprintf(w, "%d. %s %s\n", i+1, tag, ext.DisplayName())
+	} else {
+		fmt.Fprintf(w, "%d. %s\n", i+1, ext.DisplayName())
+	}
+	fmt.Fprintf(w, "   Browser: %s\n", ext.Browser)
+	if ext.Type != "" && ext.Type != browsers.TypeExtension {
+		fmt.Fprintf(w, "   Type: %s\n", ext.Type)
+	}
+	if ext.VersionName != "" && ext.VersionName != ext.Version {
+		fmt.Fprintf(w, "   Version: %s (%s)\n", ext.Version, ext.VersionName)
+	} else {
+		fmt.Fprintf(w, "   Version: %s\n", ext.Version)
+	}
+	if ext.MinBrowserVersion != "" {
+		fmt.Fprintf(w, "   Minimum Browser Version: %s\n", ext.MinBrowserVersion)
+	}
+	fmt.Fprintf(w, "   ID: %s\n", ext.ID)
+	if ext.KnownStatus != "" {
+		if ext.Risk != "" {
+			fmt.Fprintf(w, "   Known: %s (risk: %s)\n", ext.KnownStatus, ext.Risk)
+		} else {
+			fmt.Fprintf(w, "   Known: %s\n", ext.KnownStatus)
+		}
+	}
+	if ext.StoreStatus != "" {
+		if ext.StoreLatestVersion != "" {
+			fmt.Fprintf(w, "   Store: %s (latest %s)\n", ext.StoreStatus, ext.StoreLatestVersion)
+		} else {
+			fmt.Fprintf(w, "   Store: %s\n", ext.StoreStatus)
+		}
+	}
+	fmt.Fprintf(w, "   Enabled: %v\n", ext.Enabled)
+	if ext.Builtin {
+		fmt.Fprintln(w, "   Builtin: true")
+	}
+	if ext.InstallSource != "" {
+		fmt.Fprintf(w, "   Install Source: %s\n", ext.InstallSource)
+	}
+	if ext.InstalledBy != "" {
+		fmt.Fprintf(w, "   Installed By: %s\n", ext.InstalledBy)
+	}
+	if ext.DuplicateInProfile {
+		fmt.Fprintln(w, "   Duplicate In Profile: true")
+	}
+	if ext.IncognitoAllowed {
+		fmt.Fprintln(w, "   Incognito Allowed: true")
+	}
+	if ext.IDMismatch {
+		fmt.Fprintf(w, "   ID Mismatch: key yields %s\n", ext.ComputedID)
+	}
+	if ext.Verified != nil {
+		if *ext.Verified {
+			fmt.Fprintln(w, "   Verified: true")
+		} else {
+			fmt.Fprintf(w, "   Verified: false (modified: %s)\n", strings.Join(ext.ModifiedFiles, ", "))
+		}
+	}
+	if ext.MarkedForDeletion {
+		fmt.Fprintln(w, "   Marked For Deletion: true")
+	}
+	if ext.DisabledReason != "" {
+		fmt.Fprintf(w, "   Disabled Reason: %s\n", ext.DisabledReason)
+	}
+	if ext.User != "" {
+		fmt.Fprintf(w, "   User: %s\n", ext.User)
+	}
+	if ext.Profile != "" {
+		fmt.Fprintf(w, "   Profile: %s\n", ext.Profile)
+	}
+	if len(ext.Profiles) > 0 {
+		fmt.Fprintf(w, "   Profiles: %s\n", strings.Join(ext.Profiles, ", "))
+	}
+	if ext.FirstSeen != nil {
+		fmt.Fprintf(w, "   First Seen: %s\n", style.timestamp(*ext.FirstSeen))
+	}
+	if detailed {
+		if ext.InstalledAt != nil {
+			fmt.Fprintf(w, "   Installed: %s\n", style.timestamp(*ext.InstalledAt))
+		}
+		if ext.UpdatedAt != nil && (ext.InstalledAt == nil || !ext.UpdatedAt.Equal(*ext.InstalledAt)) {
+			fmt.Fprintf(w, "   Updated: %s\n", style.timestamp(*ext.UpdatedAt))
+		}
+		if ext.Name != ext.DisplayName() {
+			fmt.Fprintf(w, "   Manifest Name: %s\n", ext.Name)
+		}
+		if len(ext.Permissions) > 0 {
+			fmt.Fprintf(w, "   Permissions: %s\n", strings.Join(ext.Permissions, ", "))
+		}
+		if len(ext.HostPermissions) > 0 {
+			fmt.Fprintf(w, "   Host Permissions: %s\n", strings.Join(ext.HostPermissions, ", "))
+		}
+		if len(ext.GrantedPermissions) > 0 {
+			fmt.Fprintf(w, "   Granted Permissions: %s\n", strings.Join(ext.GrantedPermissions, ", "))
+		}
+		if len(ext.GrantedHostPermissions) > 0 {
+			fmt.Fprintf(w, "   Granted Host Permissions: %s\n", strings.Join(ext.GrantedHostPermissions, ", "))
+		}
+		if ext.Path != "" {
+			fmt.Fprintf(w, "   Path: %s\n", ext.Path)
+		}
+		if ext.Author != "" {
+			fmt.Fprintf(w, "   Author: %s\n", ext.Author)
+		}
+		if ext.Homepage != "" {
+			fmt.Fprintf(w, "   Homepage: %s\n", ext.Homepage)
+		}
+		if ext.UpdateURL != "" {
+			fmt.Fprintf(w, "   Update URL: %s\n", ext.UpdateURL)
+		}
+		if ext.Rating != 0 {
+			fmt.Fprintf(w, "   Rating: %.1f\n", ext.Rating)
+		}
+	}
+	fmt.Fprintln(w, "------------------")
+}
+
+// riskTags are the console tags for each -known risk level, with the ANSI
+// color used on a terminal
+var riskTags = map[string]struct{ tag, color string }{
+	"low":      {"[LOW]", "\x1b[32m"},
+	"medium":   {"[MED]", "\x1b[33m"},
+	"high":     {"[HIGH]", "\x1b[31m"},
+	"critical": {"[CRIT]", "\x1b[1;31m"},
+}
+
+// riskTag returns the console tag for a risk rating, colored when color is
+// set, or "" for unrated extensions and ratings outside known.RiskLevels
+func riskTag(risk string, color bool) string {
+	rank, ok := known.RiskRank(risk)
+	if !ok {
+		return ""
+	}
+	t := riskTags[known.RiskLevels[rank]]
+	if !color {
+		return t.tag
+	}
+	return t.color + t.tag + "\x1b[0m"
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a
+// file or pipe
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printPermissionSummary writes the -permission-summary table
+func printPermissionSummary(w io.Writer, summary []browsers.PermissionCount, total int) {
+	if len(summary) == 0 {
+		fmt.Fprintln(w, "No permissions requested.")
+		return
+	}
+
+	fmt.Fprintln(w, "Permission Usage:")
+	fmt.Fprintln(w, "=================")
+	for _, p := range summary {
+		fmt.Fprintf(w, "%5d  %-10s %s\n", p.Count, p.Kind, p.Permission)
+	}
+	fmt.Fprintf(w, "Total extensions: %d\n", total)
+}
+
+// printDuplicates writes the console listing of duplicated extension IDs
+func printDuplicates(w io.Writer, groups []browsers.DuplicateGroup) {
+	if len(groups) == 0 {
+		fmt.Fprintln(w, "No duplicate extensions found.")
+		return
+	}
+
+	fmt.Fprintln(w, "Duplicate Extensions:")
+	fmt.Fprintln(w, "=====================")
+	for i, g := range groups {
+		fmt.Fprintf(w, "%d. %s\n", i+1, g.Name)
+		fmt.Fprintf(w, "   ID: %s\n", g.ID)
+		for _, in := range g.Installs {
+			location := in.Browser
+			if in.Profile != "" {
+				location += " / " + in.Profile
+			}
+			fmt.Fprintf(w, "   - %s (version %s)\n", location, in.Version)
+			if in.Path != "" {
+				fmt.Fprintf(w, "     Path: %s\n", in.Path)
+			}
+		}
+		fmt.Fprintln(w, "------------------")
+	}
+	fmt.Fprintf(w, "Total duplicated IDs: %d\n", len(groups))
+}
+
+// warnKeyConflicts reports each -verify-ids key conflict on stderr, so the
+// finding isn't lost in machine-readable output
+func warnKeyConflicts(conflicts []browsers.KeyConflict) {
+	for _, c := range conflicts {
+		fmt.Fprintf(os.Stderr, "Warning: [%s] %s (%s) has manifest keys for different IDs:\n", c.Severity, c.Name, c.ID)
+		for _, in := range c.Installs {
+			location := in.Browser
+			if in.Profile != "" {
+				location += " / " + in.Profile
+			}
+			computed := in.ComputedID
+			if computed == "" {
+				computed = "no key"
+			}
+			fmt.Fprintf(os.Stderr, "   - %s: %s (key yields %s)\n", location, in.Path, computed)
+		}
+	}
+}
+
+// printProfiles writes a browser -> profiles tree, including browsers with
+// no profiles so gaps are visible
+func printProfiles(w io.Writer, browserList []string, profiles []browsers.Profile) {
+	for _, b := range browserList {
+		fmt.Fprintf(w, "%s:\n", b)
+		var found bool
+		for _, p := range profiles {
+			if p.Browser != b {
+				continue
+			}
+			found = true
+			line := p.Name
+			if p.Dir != p.Name {
+				line += " (" + p.Dir + ")"
+			}
+			if p.Default {
+				line += " [default]"
+			}
+			fmt.Fprintf(w, "   %s\n", line)
+		}
+		if !found {
+			fmt.Fprintln(w, "   (no profiles)")
+		}
+	}
+	fmt.Fprintf(w, "Total profiles: %d\n", len(profiles))
+}
+
+// printOrphans writes the console listing of orphaned extension directories
+func printOrphans(w io.Writer, orphans []browsers.Orphan) {
+	if len(orphans) == 0 {
+		fmt.Fprintln(w, "No orphaned extension directories found.")
+		return
+	}
+
+	fmt.Fprintln(w, "Orphaned Extension Directories:")
+	fmt.Fprintln(w, "===============================")
+	var total int64
+	for i, o := range orphans {
+		fmt.Fprintf(w, "%d. %s\n", i+1, o.ID)
+		fmt.Fprintf(w, "   Browser: %s\n", o.Browser)
+		fmt.Fprintf(w, "   Profile: %s\n", o.Profile)
+		fmt.Fprintf(w, "   Path: %s\n", o.Path)
+		fmt.Fprintf(w, "   Size: %s\n", formatSize(o.Size))
+		fmt.Fprintln(w, "------------------")
+		total += o.Size
+	}
+	fmt.Fprintf(w, "Total orphaned directories: %d (%s)\n", len(orphans), formatSize(total))
+}
+
+// printComparison prints a -compare-hosts report: extensions on every host,
+// on some hosts, and per host the ones found nowhere else
+func printComparison(w io.Writer, c compareOutput) {
+	fmt.Fprintf(w, "Compared %d hosts: %s\n", len(c.Hosts), strings.Join(c.Hosts, ", "))
+	fmt.Fprintln(w, "===================")
+
+	fmt.Fprintf(w, "Common to all hosts (%d):\n", len(c.Common))
+	for _, ext := range c.Common {
+		fmt.Fprintf(w, "   %s  %s\n", ext.ID, ext.Name)
+	}
+	fmt.Fprintln(w, "------------------")
+
+	fmt.Fprintf(w, "On some hosts (%d):\n", len(c.Partial))
+	for _, ext := range c.Partial {
+		fmt.Fprintf(w, "   %s  %s  [%s]\n", ext.ID, ext.Name, strings.Join(ext.Hosts, ", "))
+	}
+	fmt.Fprintln(w, "------------------")
+
+	for _, delta := range c.Unique {
+		fmt.Fprintf(w, "Only on %s (%d):\n", delta.Host, len(delta.Extensions))
+		for _, ext := range delta.Extensions {
+			fmt.Fprintf(w, "   %s  %s\n", ext.ID, ext.Name)
+		}
+		fmt.Fprintln(w, "------------------")
+	}
+}
+
+// formatSize renders a byte count with a binary unit
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// printBrowserStatuses writes the installed/profile summary for each browser,
+// noting the age of results that were served from the cache and, when some
+// were, which browsers were scanned this run
+func printBrowserStatuses(w io.Writer, statuses []browsers.BrowserStatus, dataMeta map[string]browserDataMeta) {
+	if len(statuses) == 0 {
+		return
+	}
+	var anyCached bool
+	for _, dm := range dataMeta {
+		if dm.Source == sourceCache {
+			anyCached = true
+			break
+		}
+	}
+	fmt.Fprintln(w, "Browsers:")
+	for _, st := range statuses {
+		var line string
+		switch {
+		case !st.Installed:
+			line = "not installed"
+		case !st.HasProfile:
+			line = "installed, no profile data yet"
+		default:
+			line = "installed, profile found"
+		}
+		if dm, ok := dataMeta[st.Browser]; ok {
+			if dm.ProfilesScanned != nil && st.HasProfile {
+				line += fmt.Sprintf(", profiles scanned: %d", *dm.ProfilesScanned)
+			}
+			switch {
+			case dm.Source == sourceCache:
+				line += fmt.Sprintf(" (cached %s ago)", formatAge(time.Duration(dm.AgeSeconds)*time.Second))
+			case anyCached && st.HasProfile:
+				line += " (scanned this run)"
+			}
+		}
+		fmt.Fprintf(w, "   %s: %s\n", st.Browser, line)
+	}
+}
+
+// consoleStyle holds the console output choices that depend on where output
+// goes and on flags
+type consoleStyle struct {
+	// color enables ANSI colors for risk tags
+	color bool
+	// relativeTime renders timestamps as "3 days ago" (-relative-time)
+	relativeTime bool
+}
+
+// timestamp renders t as RFC3339, or relative to now with -relative-time
+func (s consoleStyle) timestamp(t time.Time) string {
+	if s.relativeTime {
+		return humanizeTime(t, time.Now())
+	}
+	return t.Format(time.RFC3339)
+}
+
+// humanizeTime describes t relative to now in its largest whole unit: "just
+// now" under a minute, then minutes, hours, and days ago. Times in the future
+// (clock skew) are "just now".
+func humanizeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit + " ago"
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d.Minutes()), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d.Hours()), "hour")
+	default:
+		return plural(int(d.Hours()/24), "day")
+	}
+}
+
+// formatAge renders a duration in its largest whole unit, e.g. 12m or 3h
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// printViolations writes the console report for a policy evaluation
+func printViolations(w io.Writer, mode string, violations []browsers.Extension, style consoleStyle) {
+	if len(violations) == 0 {
+		fmt.Fprintf(w, "Policy (%s): compliant, no violations found.\n", mode)
+		return
+	}
+
+	fmt.Fprintf(w, "Policy Violations (%s):\n", mode)
+	fmt.Fprintln(w, "===================")
+	for i, ext := range violations {
+		printExtension(w, i, ext, false, style)
+	}
+	fmt.Fprintf(w, "Total violations: %d\n", len(violations))
+}
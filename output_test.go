@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"go-browser-inventory/internal/browsers"
+)
+
+// TestTOMLRoundTrip encodes a report as TOML and decodes it back into output
+func TestTOMLRoundTrip(t *testing.T) {
+	installed := time.Date(2022, 7, 25, 13, 20, 0, 0, time.UTC)
+	updated := time.Date(2024, 1, 17, 21, 20, 0, 123456000, time.UTC)
+	verified := true
+	profiles := 2
+	r := report{
+		extensions: []browsers.Extension{
+			{
+				Name:            "Locale Resolved Extension",
+				ShortName:       "Locale Ext",
+				Version:         "3.2.1",
+				VersionName:     "3.2 Beta",
+				ID:              "aaaabbbbccccddddeeeeffffgggghhhh",
+				Enabled:         true,
+				Browser:         "Chrome",
+				Profile:         "Person 1",
+				Permissions:     []string{"storage", "tabs"},
+				HostPermissions: []string{"https://*.example.com/*"},
+				Rating:          4.5,
+				InstalledAt:     &installed,
+				UpdatedAt:       &updated,
+				Verified:        &verified,
+				ModifiedFiles:   []string{"background.js"},
+			},
+			{
+				Name:           "Disabled Firefox Add-on",
+				Version:        "0.1",
+				ID:             "disabled@example.com",
+				DisabledReason: "user",
+				Browser:        "Firefox",
+				InstalledBy:    "external",
+			},
+		},
+		statuses: []browsers.BrowserStatus{{Browser: "Chrome", Installed: true, InstallPath: "/opt/google/chrome/chrome", HasProfile: true}},
+		meta: &scanMeta{
+			Hostname:    "host",
+			OS:          "linux",
+			Timestamp:   "2024-01-17T21:20:00Z",
+			ToolVersion: "dev",
+			Source:      sourceFresh,
+			Browsers:    []browserDataMeta{{Browser: "Chrome", Source: sourceFresh, UpdatedAt: "2024-01-17T21:20:00Z", ProfilesScanned: &profiles}},
+		},
+		fileErrors: []browsers.FileError{{Path: "/tmp/manifest.json", Error: "unexpected end of JSON input"}},
+		skipped:    []browsers.SkippedPath{{Path: "/tmp/Profile 2", Reason: "permission denied"}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeReport(&buf, outputTOML, r); err != nil {
+		t.Fatalf("writeReport: %v", err)
+	}
+	var got output
+	if _, err := toml.Decode(buf.String(), &got); err != nil {
+		t.Fatalf("decoding:\n%s\n%v", buf.String(), err)
+	}
+	if want := r.output(); !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch\ngot:  %+v\nwant: %+v\nTOML:\n%s", got, want, buf.String())
+	}
+}
+
+func TestHumanizeTime(t *testing.T) {
+	now := time.Date(2024, 1, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{-time.Hour, "just now"},
+		{0, "just now"},
+		{59 * time.Second, "just now"},
+		{time.Minute, "1 minute ago"},
+		{119 * time.Second, "1 minute ago"},
+		{2 * time.Minute, "2 minutes ago"},
+		{59*time.Minute + 59*time.Second, "59 minutes ago"},
+		{time.Hour, "1 hour ago"},
+		{2 * time.Hour, "2 hours ago"},
+		{23*time.Hour + 59*time.Minute, "23 hours ago"},
+		{24 * time.Hour, "1 day ago"},
+		{47 * time.Hour, "1 day ago"},
+		{48 * time.Hour, "2 days ago"},
+		{400 * 24 * time.Hour, "400 days ago"},
+	}
+	for _, tt := range tests {
+		if got := humanizeTime(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("humanizeTime(now - %v) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+}
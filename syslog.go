@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"go-browser-inventory/internal/browsers"
+)
+
+// defaultSyslogTag identifies the tool's messages in the system log
+const defaultSyslogTag = "go-browser-inventory"
+
+// syslogMessages formats the inventory as syslog messages: one key=value line
+// per extension, or a single summary line when summary is set
+func syslogMessages(exts []browsers.Extension, failedBrowsers int, summary bool) []string {
+	if summary {
+		browserSet := make(map[string]bool)
+		var names []string
+		for _, ext := range exts {
+			if !browserSet[ext.Browser] {
+				browserSet[ext.Browser] = true
+				names = append(names, ext.Browser)
+			}
+		}
+		return []string{fmt.Sprintf("total=%d unique=%d browsers=%q failed_browsers=%d",
+			len(exts), len(uniqueIDs(exts)), strings.Join(names, ","), failedBrowsers)}
+	}
+
+	messages := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		var b strings.Builder
+		fmt.Fprintf(&b, "browser=%q", ext.Browser)
+		if ext.User != "" {
+			fmt.Fprintf(&b, " user=%q", ext.User)
+		}
+		fmt.Fprintf(&b, " profile=%q id=%q name=%q version=%q enabled=%t", ext.Profile, ext.ID, ext.DisplayName(), ext.Version, ext.Enabled)
+		if ext.KnownStatus != "" {
+			fmt.Fprintf(&b, " known_status=%q", ext.KnownStatus)
+		}
+		if ext.Risk != "" {
+			fmt.Fprintf(&b, " risk=%q", ext.Risk)
+		}
+		messages = append(messages, b.String())
+	}
+	return messages
+}
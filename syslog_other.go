@@ -0,0 +1,16 @@
+//go:build windows || plan9
+
+package main
+
+// syslogAvailable reports whether this platform has a local syslog daemon
+const syslogAvailable = false
+
+// checkSyslogFacility accepts any name; -syslog is a no-op on this platform
+func checkSyslogFacility(name string) error {
+	return nil
+}
+
+// sendSyslog is never called when syslogAvailable is false
+func sendSyslog(facility, tag string, messages []string) error {
+	return nil
+}
@@ -0,0 +1,65 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"fmt"
+	"log/syslog"
+	"sort"
+	"strings"
+)
+
+// syslogFacilities maps -syslog-facility names to their priorities
+var syslogFacilities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+// syslogAvailable reports whether this platform has a local syslog daemon
+const syslogAvailable = true
+
+// checkSyslogFacility rejects facility names log/syslog doesn't know
+func checkSyslogFacility(name string) error {
+	if _, ok := syslogFacilities[strings.ToLower(name)]; ok {
+		return nil
+	}
+	valid := make([]string, 0, len(syslogFacilities))
+	for f := range syslogFacilities {
+		valid = append(valid, f)
+	}
+	sort.Strings(valid)
+	return fmt.Errorf("unknown syslog facility %q (valid: %s)", name, strings.Join(valid, ", "))
+}
+
+// sendSyslog writes each message to the local syslog daemon at info severity
+func sendSyslog(facility, tag string, messages []string) error {
+	w, err := syslog.New(syslogFacilities[strings.ToLower(facility)]|syslog.LOG_INFO, tag)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	defer w.Close()
+	for _, msg := range messages {
+		if err := w.Info(msg); err != nil {
+			return fmt.Errorf("failed to write to syslog: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-browser-inventory/internal/browsers"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// tuiColumn is one column of the -tui table
+type tuiColumn struct {
+	title string
+	value func(ext browsers.Extension) string
+	// less orders by this column; nil compares values case-insensitively
+	less func(a, b browsers.Extension) bool
+}
+
+// tuiColumns are the -tui table columns, in display order
+var tuiColumns = []tuiColumn{
+	{title: "Browser", value: func(ext browsers.Extension) string { return ext.Browser }},
+	{title: "Profile", value: func(ext browsers.Extension) string { return ext.Profile }},
+	{title: "Name", value: func(ext browsers.Extension) string { return ext.DisplayName() }},
+	{title: "Version", value: func(ext browsers.Extension) string { return ext.Version }},
+	{title: "Enabled", value: func(ext browsers.Extension) string { return strconv.FormatBool(ext.Enabled) }},
+	{
+		title: "Permissions",
+		value: func(ext browsers.Extension) string {
+			return strconv.Itoa(len(ext.Permissions) + len(ext.HostPermissions))
+		},
+		less: func(a, b browsers.Extension) bool {
+			return len(a.Permissions)+len(a.HostPermissions) < len(b.Permissions)+len(b.HostPermissions)
+		},
+	},
+	{title: "ID", value: func(ext browsers.Extension) string { return ext.ID }},
+}
+
+// tuiHelp is the key reference shown under the table
+const tuiHelp = "Type to filter  ↑/↓ select  Enter details  Tab sort column  Shift-Tab reverse  Esc clear/quit"
+
+// runTUI shows the extensions in an interactive table for -tui: typing
+// filters, Tab and Shift-Tab change the sort, and Enter toggles the details of
+// the selected extension, rendered like the console output. It returns when
+// the user quits.
+func runTUI(extensions []browsers.Extension, style consoleStyle) error {
+	app := tview.NewApplication()
+	filter := tview.NewInputField().SetLabel("Filter: ")
+	table := tview.NewTable().SetFixed(1, 0).SetSelectable(true, false)
+	details := tview.NewTextView().SetDynamicColors(false).SetScrollable(true)
+	details.SetBorder(true).SetTitle(" Details ")
+	status := tview.NewTextView()
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(filter, 1, 0, true).
+		AddItem(table, 0, 1, false).
+		AddItem(status, 1, 0, false)
+
+	sortColumn, descending := 2, false // Name, ascending
+	var shown []browsers.Extension
+	showDetails := false
+	style.color = false // tview draws its own colors
+
+	renderDetails := func() {
+		row, _ := table.GetSelection()
+		details.Clear()
+		if row < 1 || row > len(shown) {
+			return
+		}
+		var buf bytes.Buffer
+		printExtension(&buf, row-1, shown[row-1], true, style)
+		details.SetText(buf.String()).ScrollToBeginning()
+	}
+
+	refresh := func() {
+		query := strings.ToLower(strings.TrimSpace(filter.GetText()))
+		shown = shown[:0]
+		for _, ext := range extensions {
+			if query == "" || strings.Contains(tuiSearchText(ext), query) {
+				shown = append(shown, ext)
+			}
+		}
+		col := tuiColumns[sortColumn]
+		sort.SliceStable(shown, func(i, j int) bool {
+			a, b := shown[i], shown[j]
+			if descending {
+				a, b = b, a
+			}
+			if col.less != nil {
+				return col.less(a, b)
+			}
+			return strings.ToLower(col.value(a)) < strings.ToLower(col.value(b))
+		})
+
+		table.Clear()
+		for c, column := range tuiColumns {
+			title := column.title
+			if c == sortColumn {
+				title += map[bool]string{false: " ▲", true: " ▼"}[descending]
+			}
+			table.SetCell(0, c, tview.NewTableCell(title).SetSelectable(false).SetAttributes(tcell.AttrBold))
+		}
+		for r, ext := range shown {
+			for c, column := range tuiColumns {
+				cell := tview.NewTableCell(tview.Escape(column.value(ext)))
+				if c == 2 {
+					cell.SetExpansion(1)
+				}
+				table.SetCell(r+1, c, cell)
+			}
+		}
+		table.Select(1, 0).ScrollToBeginning()
+		status.SetText(fmt.Sprintf("%d of %d extensions  %s", len(shown), len(extensions), tuiHelp))
+		renderDetails()
+	}
+
+	table.SetSelectionChangedFunc(func(row, column int) {
+		if showDetails {
+			renderDetails()
+		}
+	})
+	filter.SetChangedFunc(func(string) { refresh() })
+
+	// Focus stays on the filter so typing always filters; navigation keys
+	// are passed on to the table
+	filter.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn, tcell.KeyHome, tcell.KeyEnd:
+			table.InputHandler()(event, nil)
+			return nil
+		case tcell.KeyEnter:
+			showDetails = !showDetails
+			layout.RemoveItem(details)
+			if showDetails {
+				layout.AddItem(details, 0, 1, false)
+				// Keep the status line last
+				layout.RemoveItem(status).AddItem(status, 1, 0, false)
+				renderDetails()
+			}
+			return nil
+		case tcell.KeyTab:
+			sortColumn = (sortColumn + 1) % len(tuiColumns)
+			refresh()
+			return nil
+		case tcell.KeyBacktab:
+			descending = !descending
+			refresh()
+			return nil
+		case tcell.KeyEscape:
+			if filter.GetText() == "" {
+				app.Stop()
+			} else {
+				filter.SetText("")
+			}
+			return nil
+		}
+		return event
+	})
+
+	refresh()
+	return app.SetRoot(layout, true).SetFocus(filter).Run()
+}
+
+// tuiSearchText is the lowercased text -tui filters an extension by
+func tuiSearchText(ext browsers.Extension) string {
+	fields := []string{ext.Browser, ext.Profile, ext.User, ext.Name, ext.ShortName, ext.FriendlyName, ext.ID, ext.Version, ext.Author}
+	fields = append(fields, ext.Permissions...)
+	fields = append(fields, ext.HostPermissions...)
+	return strings.ToLower(strings.Join(fields, "\n"))
+}